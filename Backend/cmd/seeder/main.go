@@ -118,7 +118,7 @@ func main() {
 		var govSpeakers []models.Speaker
 		db.Where("team_id = ?", govTeam.ID).Find(&govSpeakers)
 		for k, sp := range govSpeakers {
-			score := 75 + rand.Intn(6) // 75-80
+			score := float64(75 + rand.Intn(6)) // 75-80
 			if winner.ID == govTeam.ID {
 				score += 2 // Bonus for winning team
 			}
@@ -135,7 +135,7 @@ func main() {
 		var oppSpeakers []models.Speaker
 		db.Where("team_id = ?", oppTeam.ID).Find(&oppSpeakers)
 		for k, sp := range oppSpeakers {
-			score := 75 + rand.Intn(6)
+			score := float64(75 + rand.Intn(6))
 			if winner.ID == oppTeam.ID {
 				score += 2
 			}
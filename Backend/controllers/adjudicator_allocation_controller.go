@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Cara menentukan bobot debat untuk alokasi juri
+const (
+	WeightByBracket    = "bracket"    // Total VP kedua tim
+	WeightByImportance = "importance" // Match.Importance, bracket sebagai tie-break
+)
+
+// adjRequest: kebutuhan panel sebuah match
+type adjRequest struct {
+	MatchID uint
+	Weight  int
+	Bracket int
+	TeamIDs []uint
+}
+
+// loadJudgedTeams mengembalikan tim yang pernah dinilai tiap juri di ronde lain turnamen ini
+func loadJudgedTeams(db *gorm.DB, round models.Round) (map[uint]map[uint]bool, error) {
+	var rows []struct {
+		AdjudicatorID uint
+		MatchID       uint
+	}
+	if err := db.Table("match_adjudicators").
+		Select("match_adjudicators.adjudicator_id, match_adjudicators.match_id").
+		Joins("JOIN matches ON matches.id = match_adjudicators.match_id").
+		Joins("JOIN rounds ON rounds.id = matches.round_id").
+		Where("rounds.tournament_id = ? AND matches.round_id <> ?", round.TournamentID, round.ID).
+		Where("matches.deleted_at IS NULL AND match_adjudicators.deleted_at IS NULL").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return map[uint]map[uint]bool{}, nil
+	}
+
+	matchIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		matchIDs = append(matchIDs, row.MatchID)
+	}
+	var matches []models.Match
+	if err := db.Where("id IN ?", matchIDs).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	teamsOf := make(map[uint][]uint)
+	for _, match := range matches {
+		teamsOf[match.ID] = matchTeamIDs(match)
+	}
+
+	judged := make(map[uint]map[uint]bool)
+	for _, row := range rows {
+		if judged[row.AdjudicatorID] == nil {
+			judged[row.AdjudicatorID] = make(map[uint]bool)
+		}
+		for _, teamID := range teamsOf[row.MatchID] {
+			judged[row.AdjudicatorID][teamID] = true
+		}
+	}
+	return judged, nil
+}
+
+// allocateAdjudicators membagikan juri ke match. Juri diurutkan dari skor tertinggi; match
+// terpenting mendapat chair terbaik, lalu slot panellist diisi bergiliran dari match terpenting.
+// Konflik tidak pernah dilanggar. Riwayat "sudah pernah menilai tim ini" hanya dilonggarkan
+// jika tidak ada juri lain, dan dicatat sebagai warning.
+func allocateAdjudicators(requests []adjRequest, adjudicators []models.Adjudicator, index *conflictIndex, judged map[uint]map[uint]bool, panelSize int) (map[uint][]panelMember, []drawIssue) {
+	issues := []drawIssue{}
+	ordered := make([]adjRequest, len(requests))
+	copy(ordered, requests)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Weight != ordered[j].Weight {
+			return ordered[i].Weight > ordered[j].Weight
+		}
+		if ordered[i].Bracket != ordered[j].Bracket {
+			return ordered[i].Bracket > ordered[j].Bracket
+		}
+		return ordered[i].MatchID < ordered[j].MatchID
+	})
+
+	pool := make([]models.Adjudicator, len(adjudicators))
+	copy(pool, adjudicators)
+	sort.SliceStable(pool, func(i, j int) bool {
+		if pool[i].Score != pool[j].Score {
+			return pool[i].Score > pool[j].Score
+		}
+		return pool[i].ID < pool[j].ID
+	})
+
+	used := make(map[uint]bool)
+	panels := make(map[uint][]panelMember)
+	pick := func(req adjRequest, allowRepeat bool) *models.Adjudicator {
+		var adjIDs []uint
+		for _, member := range panels[req.MatchID] {
+			adjIDs = append(adjIDs, member.AdjudicatorID)
+		}
+		for i := range pool {
+			adj := pool[i]
+			if used[adj.ID] {
+				continue
+			}
+			if len(index.panelConflicts(req.TeamIDs, append(adjIDs, adj.ID))) > 0 {
+				continue
+			}
+			if !allowRepeat {
+				repeat := false
+				for _, teamID := range req.TeamIDs {
+					if judged[adj.ID][teamID] {
+						repeat = true
+					}
+				}
+				if repeat {
+					continue
+				}
+			}
+			return &pool[i]
+		}
+		return nil
+	}
+
+	for slot := 0; slot < panelSize; slot++ {
+		role := PanelRolePanellist
+		if slot == 0 {
+			role = PanelRoleChair
+		}
+		for _, req := range ordered {
+			if slot > 0 && len(panels[req.MatchID]) == 0 {
+				continue // Tanpa chair, panel tidak dilanjutkan
+			}
+			adj := pick(req, false)
+			if adj == nil {
+				adj = pick(req, true)
+				if adj != nil {
+					issues = append(issues, drawIssue{Severity: "warning", MatchID: req.MatchID, Message: fmt.Sprintf("%s has judged one of these teams before", adj.Name)})
+				}
+			}
+			if adj == nil {
+				severity := "warning"
+				if slot == 0 {
+					severity = "error"
+				}
+				issues = append(issues, drawIssue{Severity: severity, MatchID: req.MatchID, Message: fmt.Sprintf("No unconflicted adjudicator left for %s slot", role)})
+				continue
+			}
+			used[adj.ID] = true
+			panels[req.MatchID] = append(panels[req.MatchID], panelMember{AdjudicatorID: adj.ID, Role: role})
+		}
+	}
+	return panels, issues
+}
+
+// POST /api/rounds/:id/allocate-adjudicators
+// Body: {"panel_size": 3, "weight_by": "bracket"|"importance", "version": 1} (versi ronde wajib)
+// Mengganti panel chair/panellist di ronde ini dengan hasil alokasi otomatis; trainee yang sudah
+// dipasang tetap. Hanya selama draw masih draft.
+func AllocateAdjudicators(c *gin.Context) {
+	var input struct {
+		PanelSize int    `json:"panel_size"` // 0 = sebanyak mungkin (maks 3) dari juri yang tersedia
+		WeightBy  string `json:"weight_by"`
+		Version   *int   `json:"version"` // Versi ronde yang diedit
+	}
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if input.WeightBy == "" {
+		input.WeightBy = WeightByBracket
+	}
+	if input.WeightBy != WeightByBracket && input.WeightBy != WeightByImportance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight_by must be 'bracket' or 'importance'"})
+		return
+	}
+	if input.PanelSize < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "panel_size cannot be negative"})
+		return
+	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	if !isDraftDraw(round) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Adjudicators can only be allocated while the draw is a draft"})
+		return
+	}
+
+	var matches []models.Match
+	if err := models.DB.Where("round_id = ? AND is_bye = ?", round.ID, false).Order("id asc").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(matches) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Round has no matches to allocate"})
+		return
+	}
+
+	available, err := roundAvailability(models.DB, round, EntityAdjudicator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var allAdjudicators []models.Adjudicator
+	if err := models.DB.Where("tournament_id = ?", round.TournamentID).Find(&allAdjudicators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Trainee yang sudah dipasang manual dipertahankan dan tidak ikut dialokasikan
+	existing, err := loadRoundPanels(models.DB, round.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	trainees := make(map[uint][]panelMember)
+	isTrainee := make(map[uint]bool)
+	for matchID, rows := range existing {
+		for _, row := range rows {
+			if row.Role == PanelRoleTrainee {
+				trainees[matchID] = append(trainees[matchID], panelMember{AdjudicatorID: row.AdjudicatorID, Role: row.Role})
+				isTrainee[row.AdjudicatorID] = true
+			}
+		}
+	}
+	var adjudicators []models.Adjudicator
+	for _, adj := range allAdjudicators {
+		if available[adj.ID] && !isTrainee[adj.ID] {
+			adjudicators = append(adjudicators, adj)
+		}
+	}
+
+	panelSize := input.PanelSize
+	if panelSize == 0 {
+		panelSize = len(adjudicators) / len(matches)
+		if panelSize > 3 {
+			panelSize = 3
+		}
+		if panelSize < 1 {
+			panelSize = 1
+		}
+	}
+
+	index, err := loadConflicts(models.DB, round.TournamentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	judged, err := loadJudgedTeams(models.DB, round)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var teams []models.Team
+	models.DB.Where("tournament_id = ?", round.TournamentID).Find(&teams)
+	points := make(map[uint]int)
+	for _, team := range teams {
+		points[team.ID] = team.TotalVP
+	}
+
+	requests := make([]adjRequest, 0, len(matches))
+	for _, match := range matches {
+		req := adjRequest{MatchID: match.ID, TeamIDs: matchTeamIDs(match)}
+		for _, teamID := range req.TeamIDs {
+			req.Bracket += points[teamID]
+		}
+		req.Weight = req.Bracket
+		if input.WeightBy == WeightByImportance {
+			req.Weight = match.Importance
+		}
+		requests = append(requests, req)
+	}
+
+	panels, issues := allocateAdjudicators(requests, adjudicators, index, judged, panelSize)
+
+	tx := models.DB.Begin()
+	// Draw lama bisa masih draft walau sudah ada ballot; panelnya tidak boleh diganti
+	if refuseDrawWithResults(c, tx, round.ID) {
+		return
+	}
+	if err := bumpRoundVersion(c, tx, &round, *input.Version); err != nil {
+		return
+	}
+	for _, match := range matches {
+		if err := replaceMatchPanel(tx, match, append(panels[match.ID], trainees[match.ID]...)); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	assigned := make(map[uint]bool)
+	for _, members := range panels {
+		for _, member := range members {
+			assigned[member.AdjudicatorID] = true
+		}
+	}
+	unused := []models.Adjudicator{}
+	for _, adj := range adjudicators {
+		if !assigned[adj.ID] {
+			unused = append(unused, adj)
+		}
+	}
+
+	models.DB.Where("round_id = ?", round.ID).
+		Preload("Panel", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).Preload("Panel.Adjudicator").
+		Order("id asc").Find(&matches)
+	c.JSON(http.StatusOK, gin.H{"data": matches, "issues": issues, "unused": unused, "panel_size": panelSize, "round_version": round.Version})
+}
+
+// PUT /api/matches/:id/importance
+func UpdateMatchImportance(c *gin.Context) {
+	var input struct {
+		Importance int  `json:"importance"`
+		Version    *int `json:"version"` // Versi match yang diedit
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
+
+	var match models.Match
+	if err := models.DB.First(&match, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+	updated, err := updateVersioned(models.DB, &match, *input.Version, map[string]interface{}{"importance": input.Importance})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	models.DB.First(&match, match.ID)
+	if !updated {
+		staleVersion(c, match)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": match})
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Skala skor juri (sama dengan skala tes CA)
+const (
+	AdjScoreMin = 0.0
+	AdjScoreMax = 10.0
+)
+
+// feedbackSummary: ringkasan feedback seorang juri
+type feedbackSummary struct {
+	Average float64 // Rata-rata rating, dikonversi ke skala 0-10
+	Count   int     // Jumlah feedback
+	Matches int     // Jumlah match berbeda yang sudah dinilai
+}
+
+func loadFeedbackSummary(db *gorm.DB, adjudicatorID uint) (feedbackSummary, error) {
+	var row struct {
+		Avg     float64
+		Total   int
+		Matches int
+	}
+	err := db.Model(&models.AdjudicatorFeedback{}).
+		Select("COALESCE(AVG(rating), 0) AS avg, COUNT(*) AS total, COUNT(DISTINCT match_id) AS matches").
+		Where("adjudicator_id = ?", adjudicatorID).
+		Scan(&row).Error
+	// Rating 1-5 bintang -> skala 0-10 (1 = 0, 5 = 10)
+	average := 0.0
+	if row.Total > 0 {
+		average = (row.Avg - 1) * 2.5
+	}
+	return feedbackSummary{Average: average, Count: row.Total, Matches: row.Matches}, err
+}
+
+// blendAdjudicatorScore menggabungkan base score dengan feedback. Bobot feedback naik linear
+// per match yang dinilai hingga mencapai settings.FeedbackWeight.
+func blendAdjudicatorScore(base float64, feedback feedbackSummary, settings models.TournamentSettings) float64 {
+	if feedback.Count == 0 {
+		return base
+	}
+	weight := settings.FeedbackWeight
+	if settings.FeedbackFullWeightAfter > 0 && feedback.Matches < settings.FeedbackFullWeightAfter {
+		weight *= float64(feedback.Matches) / float64(settings.FeedbackFullWeightAfter)
+	}
+	score := (1-weight)*base + weight*feedback.Average
+	return math.Round(score*100) / 100
+}
+
+// recalculateAdjudicatorScore menghitung ulang skor efektif juri dan mencatat riwayat jika berubah
+func recalculateAdjudicatorScore(db *gorm.DB, adj *models.Adjudicator, source, note string) error {
+	settings, err := loadTournamentSettings(db, adj.TournamentID)
+	if err != nil {
+		return err
+	}
+	feedback, err := loadFeedbackSummary(db, adj.ID)
+	if err != nil {
+		return err
+	}
+
+	score := blendAdjudicatorScore(adj.BaseScore, feedback, settings)
+	if adj.ScoreOverride != nil {
+		score = *adj.ScoreOverride
+	}
+
+	var last models.AdjudicatorScoreHistory
+	hasHistory := db.Where("adjudicator_id = ?", adj.ID).Order("id desc").Limit(1).Find(&last).RowsAffected > 0
+	unchanged := hasHistory && last.Score == score && last.BaseScore == adj.BaseScore &&
+		(last.ScoreOverride == nil) == (adj.ScoreOverride == nil) &&
+		(adj.ScoreOverride == nil || *last.ScoreOverride == *adj.ScoreOverride)
+
+	adj.Score = score
+	if err := db.Model(&models.Adjudicator{}).Where("id = ?", adj.ID).Update("score", score).Error; err != nil {
+		return err
+	}
+	if unchanged {
+		return nil
+	}
+	return db.Create(&models.AdjudicatorScoreHistory{
+		AdjudicatorID: adj.ID,
+		TournamentID:  adj.TournamentID,
+		BaseScore:     adj.BaseScore,
+		ScoreOverride: adj.ScoreOverride,
+		FeedbackAvg:   feedback.Average,
+		FeedbackCount: feedback.Count,
+		Score:         score,
+		Source:        source,
+		Note:          note,
+	}).Error
+}
+
+func validAdjScore(score float64) bool {
+	return score >= AdjScoreMin && score <= AdjScoreMax
+}
+
+// PUT /api/adjudicators/:id/score
+// Body: {"base_score": 7.5} dan/atau {"score_override": 9} / {"clear_override": true}, opsional "note"
+func UpdateAdjudicatorScore(c *gin.Context) {
+	var input struct {
+		BaseScore     *float64 `json:"base_score"`
+		ScoreOverride *float64 `json:"score_override"`
+		ClearOverride bool     `json:"clear_override"`
+		Note          string   `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var adj models.Adjudicator
+	if err := models.DB.First(&adj, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator not found"})
+		return
+	}
+
+	source := "base"
+	if input.BaseScore != nil {
+		if !validAdjScore(*input.BaseScore) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "base_score must be between 0 and 10"})
+			return
+		}
+		adj.BaseScore = *input.BaseScore
+	}
+	if input.ScoreOverride != nil {
+		if !validAdjScore(*input.ScoreOverride) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "score_override must be between 0 and 10"})
+			return
+		}
+		adj.ScoreOverride = input.ScoreOverride
+		source = "override"
+	} else if input.ClearOverride {
+		adj.ScoreOverride = nil
+		source = "override"
+	}
+
+	tx := models.DB.Begin()
+	if err := tx.Model(&adj).Select("base_score", "score_override").Updates(&adj).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := recalculateAdjudicatorScore(tx, &adj, source, input.Note); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": adj})
+}
+
+// GET /api/adjudicators/:id/score-history
+func GetAdjudicatorScoreHistory(c *gin.Context) {
+	var adj models.Adjudicator
+	if err := models.DB.First(&adj, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator not found"})
+		return
+	}
+
+	var history []models.AdjudicatorScoreHistory
+	if err := models.DB.Where("adjudicator_id = ?", adj.ID).Order("id desc").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if history == nil {
+		history = []models.AdjudicatorScoreHistory{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// POST /api/tournaments/:id/adjudicator-scores/recalculate
+// Hitung ulang skor semua juri, misalnya setelah bobot feedback di settings diubah
+func RecalculateAdjudicatorScores(c *gin.Context) {
+	var tournament models.Tournament
+	if err := models.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	var adjudicators []models.Adjudicator
+	models.DB.Where("tournament_id = ?", tournament.ID).Find(&adjudicators)
+
+	tx := models.DB.Begin()
+	for i := range adjudicators {
+		if err := recalculateAdjudicatorScore(tx, &adjudicators[i], "recalculate", ""); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Adjudicator scores recalculated", "adjudicators_updated": len(adjudicators)})
+}
+
+// GET /api/adjudicators/ranking?tournament_id=1
+// Peringkat juri berdasarkan skor efektif, untuk adjudicator core
+func GetAdjudicatorRanking(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	if tournamentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id is required"})
+		return
+	}
+	if _, err := strconv.Atoi(tournamentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+		return
+	}
+
+	var adjudicators []models.Adjudicator
+	if err := models.DB.Where("tournament_id = ?", tournamentID).Find(&adjudicators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type rankingEntry struct {
+		Rank          int      `json:"rank"`
+		AdjudicatorID uint     `json:"adjudicator_id"`
+		Name          string   `json:"name"`
+		Institution   string   `json:"institution"`
+		BaseScore     float64  `json:"base_score"`
+		ScoreOverride *float64 `json:"score_override"`
+		FeedbackAvg   float64  `json:"feedback_avg"`
+		FeedbackCount int      `json:"feedback_count"`
+		Score         float64  `json:"score"`
+	}
+
+	ranking := make([]rankingEntry, 0, len(adjudicators))
+	for _, adj := range adjudicators {
+		feedback, _ := loadFeedbackSummary(models.DB, adj.ID)
+		ranking = append(ranking, rankingEntry{
+			AdjudicatorID: adj.ID,
+			Name:          adj.Name,
+			Institution:   adj.Institution,
+			BaseScore:     adj.BaseScore,
+			ScoreOverride: adj.ScoreOverride,
+			FeedbackAvg:   feedback.Average,
+			FeedbackCount: feedback.Count,
+			Score:         adj.Score,
+		})
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Score != ranking[j].Score {
+			return ranking[i].Score > ranking[j].Score
+		}
+		return ranking[i].Name < ranking[j].Name
+	})
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ranking})
+}
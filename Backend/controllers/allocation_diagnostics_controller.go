@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+)
+
+// matchDiagnostics: kualitas panel satu match
+type matchDiagnostics struct {
+	MatchID       uint              `json:"match_id"`
+	TeamIDs       []uint            `json:"team_ids"`
+	Bracket       int               `json:"bracket"`
+	Importance    int               `json:"importance"`
+	ChairID       uint              `json:"chair_id"`
+	ChairScore    float64           `json:"chair_score"`
+	PanelStrength float64           `json:"panel_strength"` // Rata-rata skor juri yang memberi suara (chair + panellist)
+	PanelSize     int               `json:"panel_size"`
+	Conflicts     []panelConflict   `json:"conflicts"`   // Konflik yang di-override saat alokasi
+	SeenBefore    []seenBeforeEntry `json:"seen_before"` // Juri yang sudah pernah menilai tim di match ini
+}
+
+type seenBeforeEntry struct {
+	AdjudicatorID uint   `json:"adjudicator_id"`
+	TeamIDs       []uint `json:"team_ids"`
+}
+
+// adjudicatorLoad: jumlah ronde juri sebagai chair / panellist / trainee di seluruh turnamen
+type adjudicatorLoad struct {
+	AdjudicatorID uint    `json:"adjudicator_id"`
+	Name          string  `json:"name"`
+	Score         float64 `json:"score"`
+	Chaired       int     `json:"chaired"`
+	Panelled      int     `json:"panelled"`
+	Trainee       int     `json:"trainee"`
+}
+
+// GET /api/rounds/:id/allocation-diagnostics
+func GetAllocationDiagnostics(c *gin.Context) {
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	var matches []models.Match
+	if err := models.DB.Where("round_id = ? AND is_bye = ?", round.ID, false).Order("id asc").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	panels, err := loadRoundPanels(models.DB, round.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	index, err := loadConflicts(models.DB, round.TournamentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	judged, err := loadJudgedTeams(models.DB, round)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var adjudicators []models.Adjudicator
+	models.DB.Where("tournament_id = ?", round.TournamentID).Order("name asc").Find(&adjudicators)
+	adjByID := make(map[uint]models.Adjudicator)
+	for _, adj := range adjudicators {
+		adjByID[adj.ID] = adj
+	}
+	var teams []models.Team
+	models.DB.Where("tournament_id = ?", round.TournamentID).Find(&teams)
+	points := make(map[uint]int)
+	for _, team := range teams {
+		points[team.ID] = team.TotalVP
+	}
+
+	assigned := make(map[uint]bool)
+	result := make([]matchDiagnostics, 0, len(matches))
+	for _, match := range matches {
+		diag := matchDiagnostics{
+			MatchID:    match.ID,
+			TeamIDs:    matchTeamIDs(match),
+			Importance: match.Importance,
+			Conflicts:  []panelConflict{},
+			SeenBefore: []seenBeforeEntry{},
+		}
+		for _, teamID := range diag.TeamIDs {
+			diag.Bracket += points[teamID]
+		}
+
+		var adjIDs []uint
+		total := 0.0
+		for _, member := range panels[match.ID] {
+			assigned[member.AdjudicatorID] = true
+			adjIDs = append(adjIDs, member.AdjudicatorID)
+			score := adjByID[member.AdjudicatorID].Score
+			if member.Role == PanelRoleChair {
+				diag.ChairID = member.AdjudicatorID
+				diag.ChairScore = score
+			}
+			if member.Role != PanelRoleTrainee {
+				total += score
+				diag.PanelSize++
+			}
+
+			var seen []uint
+			for _, teamID := range diag.TeamIDs {
+				if judged[member.AdjudicatorID][teamID] {
+					seen = append(seen, teamID)
+				}
+			}
+			if len(seen) > 0 {
+				diag.SeenBefore = append(diag.SeenBefore, seenBeforeEntry{AdjudicatorID: member.AdjudicatorID, TeamIDs: seen})
+			}
+		}
+		if diag.PanelSize > 0 {
+			diag.PanelStrength = total / float64(diag.PanelSize)
+		}
+		if conflicts := index.panelConflicts(diag.TeamIDs, adjIDs); len(conflicts) > 0 {
+			diag.Conflicts = conflicts
+		}
+		result = append(result, diag)
+	}
+
+	available, err := roundAvailability(models.DB, round, EntityAdjudicator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unused := []models.Adjudicator{}
+	for _, adj := range adjudicators {
+		if available[adj.ID] && !assigned[adj.ID] {
+			unused = append(unused, adj)
+		}
+	}
+
+	var roles []struct {
+		AdjudicatorID uint
+		Role          string
+	}
+	if err := models.DB.Table("match_adjudicators").
+		Select("match_adjudicators.adjudicator_id, match_adjudicators.role").
+		Joins("JOIN matches ON matches.id = match_adjudicators.match_id").
+		Joins("JOIN rounds ON rounds.id = matches.round_id").
+		Where("rounds.tournament_id = ? AND matches.deleted_at IS NULL AND match_adjudicators.deleted_at IS NULL", round.TournamentID).
+		Scan(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	loadByID := make(map[uint]*adjudicatorLoad)
+	loads := make([]adjudicatorLoad, 0, len(adjudicators))
+	for _, adj := range adjudicators {
+		loads = append(loads, adjudicatorLoad{AdjudicatorID: adj.ID, Name: adj.Name, Score: adj.Score})
+	}
+	for i := range loads {
+		loadByID[loads[i].AdjudicatorID] = &loads[i]
+	}
+	for _, row := range roles {
+		load, ok := loadByID[row.AdjudicatorID]
+		if !ok {
+			continue
+		}
+		switch row.Role {
+		case PanelRoleChair:
+			load.Chaired++
+		case PanelRoleTrainee:
+			load.Trainee++
+		default:
+			load.Panelled++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         result,
+		"unused":       unused,
+		"adjudicators": loads,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -104,3 +105,30 @@ func Login(c *gin.Context) {
 		"role":  user.Role,
 	})
 }
+
+// currentUserID membaca user dari header "Authorization: Bearer <token>".
+// Hasil kedua false jika token tidak ada atau tidak valid.
+func currentUserID(c *gin.Context) (uint, bool) {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return 0, false
+	}
+	token, err := jwt.Parse(strings.TrimPrefix(header, "Bearer "), func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return 0, false
+	}
+	return uint(sub), true
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Jenis entitas yang ketersediaannya diatur per ronde
+const (
+	EntityTeam        = "team"
+	EntityAdjudicator = "adjudicator"
+	EntityRoom        = "room"
+)
+
+// availabilityEntry: status ketersediaan satu entitas di satu ronde
+type availabilityEntry struct {
+	EntityID         uint   `json:"entity_id"`
+	Name             string `json:"name"`
+	IsAvailable      bool   `json:"is_available"`      // Status efektif untuk ronde ini
+	DefaultAvailable bool   `json:"default_available"` // Flag global (tanpa record per ronde)
+	CheckedIn        bool   `json:"checked_in"`        // Sudah check-in di ronde ini
+	Withdrawn        bool   `json:"withdrawn"`         // Tim sudah mundur sebelum/di ronde ini
+}
+
+// roundEntities mengambil semua entitas turnamen beserta status default-nya.
+// Jika settings.RequireCheckIn aktif, entitas yang belum check-in dianggap tidak tersedia.
+// Tim yang sudah mundur tidak pernah tersedia, apa pun override-nya.
+func roundEntities(db *gorm.DB, round models.Round, entityType string) ([]availabilityEntry, error) {
+	var entries []availabilityEntry
+	switch entityType {
+	case EntityTeam:
+		var teams []models.Team
+		if err := db.Where("tournament_id = ? AND is_swing = ?", round.TournamentID, false).Order("name asc").Find(&teams).Error; err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			withdrawn := withdrawnInRound(team, round)
+			entries = append(entries, availabilityEntry{EntityID: team.ID, Name: team.Name, DefaultAvailable: !withdrawn, Withdrawn: withdrawn})
+		}
+	case EntityAdjudicator:
+		var adjudicators []models.Adjudicator
+		if err := db.Where("tournament_id = ?", round.TournamentID).Order("name asc").Find(&adjudicators).Error; err != nil {
+			return nil, err
+		}
+		for _, adj := range adjudicators {
+			entries = append(entries, availabilityEntry{EntityID: adj.ID, Name: adj.Name, DefaultAvailable: adj.IsAvailable})
+		}
+	case EntityRoom:
+		var rooms []models.Room
+		if err := db.Where("tournament_id = ?", round.TournamentID).Order("name asc").Find(&rooms).Error; err != nil {
+			return nil, err
+		}
+		for _, room := range rooms {
+			entries = append(entries, availabilityEntry{EntityID: room.ID, Name: room.Name, DefaultAvailable: room.IsAvailable})
+		}
+	}
+
+	var records []models.RoundAvailability
+	if err := db.Where("round_id = ? AND entity_type = ?", round.ID, entityType).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	override := make(map[uint]bool)
+	for _, record := range records {
+		override[record.EntityID] = record.IsAvailable
+	}
+	settings, err := loadTournamentSettings(db, round.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+	checkedIn, err := checkedInSet(db, round, entityType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].IsAvailable = entries[i].DefaultAvailable
+		if available, ok := override[entries[i].EntityID]; ok {
+			entries[i].IsAvailable = available
+		}
+		entries[i].CheckedIn = checkedIn[entries[i].EntityID]
+		// Dengan check-in wajib, yang belum hadir tidak dianggap tersedia
+		if settings.RequireCheckIn && !entries[i].CheckedIn {
+			entries[i].IsAvailable = false
+		}
+		if entries[i].Withdrawn {
+			entries[i].IsAvailable = false
+		}
+	}
+	return entries, nil
+}
+
+// roundAvailability mengembalikan himpunan ID entitas yang tersedia di ronde ini
+func roundAvailability(db *gorm.DB, round models.Round, entityType string) (map[uint]bool, error) {
+	entries, err := roundEntities(db, round, entityType)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[uint]bool)
+	for _, entry := range entries {
+		if entry.IsAvailable {
+			available[entry.EntityID] = true
+		}
+	}
+	return available, nil
+}
+
+func validEntityType(entityType string) bool {
+	return entityType == EntityTeam || entityType == EntityAdjudicator || entityType == EntityRoom
+}
+
+// GET /api/rounds/:id/availability?entity_type=adjudicator
+func GetRoundAvailability(c *gin.Context) {
+	entityType := c.Query("entity_type")
+	if !validEntityType(entityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_type must be 'team', 'adjudicator' or 'room'"})
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	entries, err := roundEntities(models.DB, round, entityType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []availabilityEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// PUT /api/rounds/:id/availability
+// Bulk toggle: {"entity_type": "adjudicator", "ids": [1,2,3], "is_available": false}
+// atau {"entity_type": "room", "all": true, "is_available": true} untuk semua entitas.
+func UpdateRoundAvailability(c *gin.Context) {
+	var input struct {
+		EntityType  string `json:"entity_type"`
+		IDs         []uint `json:"ids"`
+		All         bool   `json:"all"`
+		IsAvailable bool   `json:"is_available"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validEntityType(input.EntityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_type must be 'team', 'adjudicator' or 'room'"})
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	entries, err := roundEntities(models.DB, round, input.EntityType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	known := make(map[uint]bool)
+	for _, entry := range entries {
+		known[entry.EntityID] = true
+	}
+
+	ids := input.IDs
+	if input.All {
+		ids = nil
+		for _, entry := range entries {
+			ids = append(ids, entry.EntityID)
+		}
+	}
+	for _, id := range ids {
+		if !known[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Some ids do not belong to this tournament", "entity_id": id})
+			return
+		}
+	}
+
+	tx := models.DB.Begin()
+	for _, id := range ids {
+		record := models.RoundAvailability{RoundID: round.ID, EntityType: input.EntityType, EntityID: id}
+		if err := tx.Where(record).FirstOrInit(&record).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		record.IsAvailable = input.IsAvailable
+		if err := tx.Save(&record).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	entries, _ = roundEntities(models.DB, round, input.EntityType)
+	c.JSON(http.StatusOK, gin.H{"data": entries, "updated": len(ids)})
+}
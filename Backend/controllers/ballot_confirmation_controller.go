@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Status ballot set
+const (
+	BallotDraft     = "draft"     // Disimpan, belum diajukan
+	BallotSubmitted = "submitted" // Diajukan, menunggu konfirmasi user lain
+	BallotConfirmed = "confirmed" // Dihitung dalam hasil & klasemen
+	BallotDiscarded = "discarded" // Diganti entri lain / ditolak
+)
+
+// countedBallotsClause membatasi query tabel ballots ke ballot dari set yang sudah dikonfirmasi
+const countedBallotsClause = "ballot_set_id IN (SELECT id FROM ballot_sets WHERE status = ? AND deleted_at IS NULL)"
+
+// ballotDiscrepancy: satu isian yang berbeda antara dua entri kertas ballot yang sama
+type ballotDiscrepancy struct {
+	Field    string      `json:"field"` // "winner", "speaker", "score", "missing"
+	TeamRole string      `json:"team_role,omitempty"`
+	Position string      `json:"position,omitempty"`
+	IsReply  bool        `json:"is_reply,omitempty"`
+	First    interface{} `json:"first"`
+	Second   interface{} `json:"second"`
+}
+
+// ballotSetWinner: pilihan pemenang manual, kalau tidak valid pakai total skor tertinggi
+func ballotSetWinner(winner string, govTotal, oppTotal float64) string {
+	if winner == "gov" || winner == "opp" {
+		return winner
+	}
+	if govTotal > oppTotal {
+		return "gov"
+	}
+	return "opp"
+}
+
+// deleteBallotSets menghapus permanen ballot set juri dengan status tertentu beserta ballot-nya
+func deleteBallotSets(tx *gorm.DB, matchID, adjudicatorID uint, isTrainee bool, status string) error {
+	var ids []uint
+	if err := tx.Model(&models.BallotSet{}).
+		Where("match_id = ? AND adjudicator_id = ? AND is_trainee = ? AND status = ?", matchID, adjudicatorID, isTrainee, status).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Where("ballot_set_id IN ?", ids).Delete(&models.Ballot{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.BallotSet{}).Error
+}
+
+// supersedeBallotSets menandai entri lain juri yang sama sebagai discarded setelah set ini berlaku
+func supersedeBallotSets(tx *gorm.DB, set models.BallotSet) error {
+	return tx.Model(&models.BallotSet{}).
+		Where("match_id = ? AND adjudicator_id = ? AND is_trainee = ? AND id <> ? AND status IN ?",
+			set.MatchID, set.AdjudicatorID, set.IsTrainee, set.ID, []string{BallotSubmitted, BallotConfirmed}).
+		Updates(map[string]interface{}{"status": BallotDiscarded, "version": gorm.Expr("version + 1")}).Error
+}
+
+// ballotSlot: kunci posisi pidato di kertas ballot
+func ballotSlot(b models.Ballot) string {
+	return fmt.Sprintf("%s-%s-%t", b.TeamRole, b.Position, b.IsReply)
+}
+
+// compareBallotSets membandingkan dua entri ballot per isian (pemenang, speaker, skor)
+func compareBallotSets(first, second models.BallotSet) []ballotDiscrepancy {
+	discrepancies := []ballotDiscrepancy{}
+	if first.Winner != second.Winner {
+		discrepancies = append(discrepancies, ballotDiscrepancy{Field: "winner", First: first.Winner, Second: second.Winner})
+	}
+
+	secondBySlot := make(map[string]models.Ballot)
+	for _, b := range second.Ballots {
+		secondBySlot[ballotSlot(b)] = b
+	}
+	seen := make(map[string]bool)
+	for _, a := range first.Ballots {
+		key := ballotSlot(a)
+		seen[key] = true
+		b, ok := secondBySlot[key]
+		if !ok {
+			discrepancies = append(discrepancies, ballotDiscrepancy{Field: "missing", TeamRole: a.TeamRole, Position: a.Position, IsReply: a.IsReply, First: a.Score, Second: nil})
+			continue
+		}
+		if a.SpeakerID != b.SpeakerID {
+			discrepancies = append(discrepancies, ballotDiscrepancy{Field: "speaker", TeamRole: a.TeamRole, Position: a.Position, IsReply: a.IsReply, First: a.SpeakerID, Second: b.SpeakerID})
+		}
+		if a.Score != b.Score {
+			discrepancies = append(discrepancies, ballotDiscrepancy{Field: "score", TeamRole: a.TeamRole, Position: a.Position, IsReply: a.IsReply, First: a.Score, Second: b.Score})
+		}
+	}
+	for _, b := range second.Ballots {
+		if !seen[ballotSlot(b)] {
+			discrepancies = append(discrepancies, ballotDiscrepancy{Field: "missing", TeamRole: b.TeamRole, Position: b.Position, IsReply: b.IsReply, First: nil, Second: b.Score})
+		}
+	}
+	return discrepancies
+}
+
+// loadBallotSet memuat ballot set beserta match-nya. Dengan lock, baris match dikunci lebih
+// dulu lalu set dibaca ulang, sehingga status set tidak berubah sampai transaksi selesai.
+func loadBallotSet(c *gin.Context, db *gorm.DB, lock bool) (models.BallotSet, models.Match, bool) {
+	var set models.BallotSet
+	if err := db.Preload("Ballots").First(&set, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ballot set not found"})
+		return set, models.Match{}, false
+	}
+	var match models.Match
+	if !lock {
+		if err := db.Preload("Round").First(&match, set.MatchID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+			return set, match, false
+		}
+		return set, match, true
+	}
+	if err := lockMatch(db, &match, set.MatchID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return set, match, false
+	}
+	set = models.BallotSet{}
+	if err := db.Preload("Ballots").First(&set, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ballot set not found"})
+		return set, match, false
+	}
+	return set, match, true
+}
+
+// POST /api/ballot-sets/:id/confirm
+// Konfirmasi ballot yang sudah diajukan. Harus dilakukan user lain dari yang menginput.
+// Body opsional berisi entri ulang kertas ballot ({"winner": "...", "scores": [...]});
+// jika berbeda, konfirmasi ditolak dan daftar perbedaannya dikembalikan. Ballot yang diajukan
+// tanpa login (penginput tidak diketahui) wajib dikonfirmasi dengan entri ulang.
+func ConfirmBallotSet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login required to confirm ballots"})
+		return
+	}
+
+	var input struct {
+		Winner  string          `json:"winner"`
+		Scores  []models.Ballot `json:"scores"`
+		Version *int            `json:"version"` // Versi ballot set yang dikonfirmasi
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tx := models.DB.Begin()
+	set, match, ok := loadBallotSet(c, tx, true)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+	if set.Status != BallotSubmitted {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Only submitted ballots can be confirmed", "status": set.Status})
+		return
+	}
+	if set.SubmittedByID != nil && *set.SubmittedByID == userID {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "Ballot must be confirmed by a different user than the one who entered it"})
+		return
+	}
+	if set.SubmittedByID == nil && len(input.Scores) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ballot was entered without login; confirm it by re-entering the scores"})
+		return
+	}
+
+	// Entri ulang: bandingkan dengan entri yang diajukan
+	if len(input.Scores) > 0 {
+		entry := models.BallotSet{Ballots: []models.Ballot{}}
+		var govTotal, oppTotal float64
+		for _, ballot := range input.Scores {
+			speaker, msg := resolveBallotSpeaker(tx, match, ballot)
+			if msg != "" {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			ballot.SpeakerID = speaker.ID
+			entry.Ballots = append(entry.Ballots, ballot)
+			if ballot.TeamRole == "gov" {
+				govTotal += ballot.Score
+			} else {
+				oppTotal += ballot.Score
+			}
+		}
+		entry.Winner = ballotSetWinner(input.Winner, govTotal, oppTotal)
+		if discrepancies := compareBallotSets(set, entry); len(discrepancies) > 0 {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Entries do not match", "discrepancies": discrepancies})
+			return
+		}
+	}
+
+	if !requireVersion(c, input.Version) {
+		tx.Rollback()
+		return
+	}
+	now := time.Now()
+	updated, err := updateVersioned(tx, &set, *input.Version, map[string]interface{}{
+		"status": BallotConfirmed, "confirmed_by_id": userID, "confirmed_at": now,
+	})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		tx.Rollback()
+		staleVersion(c, set)
+		return
+	}
+	set.Status = BallotConfirmed
+	set.ConfirmedByID = &userID
+	set.ConfirmedAt = &now
+	set.Version++
+	if err := supersedeBallotSets(tx, set); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var decision panelDecision
+	if !set.IsTrainee {
+		var tournamentID uint
+		if match.Round != nil {
+			tournamentID = match.Round.TournamentID
+		}
+		if decision, err = applyMatchDecision(tx, &match, tournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": set, "winner_id": match.WinnerID, "match_version": match.Version, "decision": decision})
+}
+
+// POST /api/ballot-sets/:id/discard
+// Menolak entri ballot. Jika set yang sudah dikonfirmasi dibuang, hasil match dihitung ulang.
+func DiscardBallotSet(c *gin.Context) {
+	var input struct {
+		Version *int `json:"version"` // Versi ballot set yang dibuang
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
+
+	tx := models.DB.Begin()
+	set, match, ok := loadBallotSet(c, tx, true)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+	if set.Status == BallotDiscarded {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Ballot set already discarded"})
+		return
+	}
+
+	wasConfirmed := set.Status == BallotConfirmed
+	updated, err := updateVersioned(tx, &set, *input.Version, map[string]interface{}{"status": BallotDiscarded})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		tx.Rollback()
+		staleVersion(c, set)
+		return
+	}
+	set.Status = BallotDiscarded
+	set.Version++
+
+	var decision panelDecision
+	if wasConfirmed && !set.IsTrainee {
+		var tournamentID uint
+		if match.Round != nil {
+			tournamentID = match.Round.TournamentID
+		}
+		if decision, err = applyMatchDecision(tx, &match, tournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": set, "match_version": match.Version, "decision": decision})
+}
+
+// GET /api/ballot-sets/:id/discrepancies?other_id=
+// Tanpa other_id, dibandingkan dengan entri submitted lain dari juri yang sama di match itu
+func GetBallotSetDiscrepancies(c *gin.Context) {
+	set, _, ok := loadBallotSet(c, models.DB, false)
+	if !ok {
+		return
+	}
+
+	var other models.BallotSet
+	if otherID := c.Query("other_id"); otherID != "" {
+		if _, err := strconv.Atoi(otherID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid other_id"})
+			return
+		}
+		if err := models.DB.Preload("Ballots").First(&other, otherID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Other ballot set not found"})
+			return
+		}
+		if other.MatchID != set.MatchID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ballot sets belong to different matches"})
+			return
+		}
+	} else if models.DB.Preload("Ballots").
+		Where("match_id = ? AND adjudicator_id = ? AND is_trainee = ? AND status = ? AND id <> ?",
+			set.MatchID, set.AdjudicatorID, set.IsTrainee, BallotSubmitted, set.ID).
+		Order("id desc").Limit(1).Find(&other).RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No other entry to compare with"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      compareBallotSets(set, other),
+		"first_id":  set.ID,
+		"second_id": other.ID,
+	})
+}
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BallotInput struct {
@@ -14,277 +19,709 @@ type BallotInput struct {
 	Adjudicator   string          `json:"adjudicator"`
 	Scores        []models.Ballot `json:"scores"`
 	Winner        string          `json:"winner"`    // "gov" or "opp" - explicit winner selection
-	GovReply      *int            `json:"gov_reply"` // Optional reply score
-	OppReply      *int            `json:"opp_reply"` // Optional reply score
+	Draft         bool            `json:"draft"`     // Simpan sebagai draft (belum diajukan)
+	GovReply      *ReplyInput     `json:"gov_reply"` // Optional reply speech (juga bisa lewat scores dengan is_reply)
+	OppReply      *ReplyInput     `json:"opp_reply"` // Optional reply speech
+
+	// Submit offline / kirim ulang: submission_id unik dari klien agar submit ganda tidak
+	// tercatat dua kali; base_revision = revisi ballot juri ini saat dicatat (0 jika belum ada)
+	SubmissionID string `json:"submission_id"`
+	BaseRevision *int   `json:"base_revision"`
+	// Versi match yang dilihat penginput; wajib jika mengganti ballot juri ini yang sudah dihitung
+	MatchVersion *int `json:"match_version"`
 }
 
-func SubmitBallot(c *gin.Context) {
-	var input BallotInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+// ReplyInput: reply speech satu tim, diberikan speaker pertama atau kedua tim itu
+type ReplyInput struct {
+	SpeakerID uint           `json:"speaker_id"`
+	Speaker   models.Speaker `json:"speaker"`
+	Score     float64        `json:"score"`
+}
+
+// expandReplies memindahkan gov_reply/opp_reply ke daftar scores sebagai baris is_reply
+func expandReplies(input *BallotInput) {
+	for _, reply := range []struct {
+		role  string
+		input *ReplyInput
+	}{{"gov", input.GovReply}, {"opp", input.OppReply}} {
+		if reply.input == nil {
+			continue
+		}
+		input.Scores = append(input.Scores, models.Ballot{
+			SpeakerID: reply.input.SpeakerID,
+			Speaker:   reply.input.Speaker,
+			Score:     reply.input.Score,
+			Position:  "Reply",
+			IsReply:   true,
+			TeamRole:  reply.role,
+		})
 	}
+	input.GovReply, input.OppReply = nil, nil
+}
 
-	// Mulai Transaksi Database (Biar Aman)
-	tx := models.DB.Begin()
+// panelDecision: keputusan panel satu match dari ballot set juri non-trainee
+type panelDecision struct {
+	Winner        string           `json:"winner"` // "gov"/"opp", kosong jika belum ada ballot
+	GovVotes      int              `json:"gov_votes"`
+	OppVotes      int              `json:"opp_votes"`
+	Received      int              `json:"received"`
+	Required      int              `json:"required"`
+	Pending       int              `json:"pending"` // Ballot submitted yang belum dikonfirmasi
+	Complete      bool             `json:"complete"`
+	TieBroken     string           `json:"tie_broken,omitempty"` // "chair"/"scores" jika suara seri
+	GovTotal      float64          `json:"gov_total"`            // Rata-rata total skor dari ballot mayoritas
+	OppTotal      float64          `json:"opp_total"`
+	SpeakerScores map[uint]float64 `json:"-"` // Rata-rata skor speaker dari ballot mayoritas (tanpa reply)
+	ReplyScores   map[uint]float64 `json:"-"` // Rata-rata skor reply speech per speaker
+}
 
-	// 0. Ambil data match terlebih dahulu untuk mendapat teamID
-	var match models.Match
-	if err := tx.Preload("GovTeam").Preload("OppTeam").First(&match, input.MatchID).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "Match tidak ditemukan"})
-		return
+// votingPanel mengembalikan juri yang memberi suara (chair + panellist) dan chair-nya.
+// Match tanpa panel tercatat boleh di-ballot juri mana pun.
+func votingPanel(db *gorm.DB, match models.Match) (map[uint]bool, uint, error) {
+	var members []models.MatchAdjudicator
+	if err := db.Where("match_id = ? AND role <> ?", match.ID, PanelRoleTrainee).Find(&members).Error; err != nil {
+		return nil, 0, err
+	}
+	voters := make(map[uint]bool)
+	var chairID uint
+	for _, member := range members {
+		voters[member.AdjudicatorID] = true
+		if member.Role == PanelRoleChair {
+			chairID = member.AdjudicatorID
+		}
 	}
+	if len(voters) == 0 && match.AdjudicatorID != nil && *match.AdjudicatorID != 0 {
+		voters[*match.AdjudicatorID] = true
+		chairID = *match.AdjudicatorID
+	}
+	return voters, chairID, nil
+}
 
-	// 0.5. Jika match sudah pernah di-ballot, revert stats lama dulu
-	if match.IsCompleted {
-		// Ambil semua ballot lama untuk match ini
-		var oldBallots []models.Ballot
-		tx.Where("match_id = ?", input.MatchID).Find(&oldBallots)
+// requiredBallots: jumlah ballot set yang dibutuhkan sebelum match dianggap selesai
+func requiredBallots(panelSize int, settings models.TournamentSettings) int {
+	if panelSize == 0 {
+		return 1
+	}
+	if settings.BallotsRequired == BallotsRequiredMajority {
+		return panelSize/2 + 1
+	}
+	return panelSize
+}
+
+// decidePanel menentukan pemenang dengan suara terbanyak; suara seri diputus sesuai
+// settings.PanelTieBreak. Skor speaker & tim dirata-rata dari ballot juri mayoritas saja.
+func decidePanel(sets []models.BallotSet, panelSize int, chairID uint, settings models.TournamentSettings) panelDecision {
+	decision := panelDecision{
+		Required:      requiredBallots(panelSize, settings),
+		Received:      len(sets),
+		SpeakerScores: make(map[uint]float64),
+		ReplyScores:   make(map[uint]float64),
+	}
+	if len(sets) == 0 {
+		return decision
+	}
 
-		// Hitung total skor lama per tim
-		var oldGovScore, oldOppScore int
-		for _, ballot := range oldBallots {
+	var chairWinner string
+	var govSum, oppSum float64
+	for _, set := range sets {
+		if set.Winner == "gov" {
+			decision.GovVotes++
+		} else {
+			decision.OppVotes++
+		}
+		if chairID != 0 && set.AdjudicatorID == chairID {
+			chairWinner = set.Winner
+		}
+		govSum += set.GovTotal
+		oppSum += set.OppTotal
+	}
+	byScores := func() string {
+		if govSum > oppSum {
+			return "gov"
+		}
+		if oppSum > govSum {
+			return "opp"
+		}
+		return ""
+	}
+
+	switch {
+	case decision.GovVotes > decision.OppVotes:
+		decision.Winner = "gov"
+	case decision.OppVotes > decision.GovVotes:
+		decision.Winner = "opp"
+	default:
+		if winner := byScores(); settings.PanelTieBreak == TieBreakScores && winner != "" {
+			decision.Winner, decision.TieBroken = winner, TieBreakScores
+		} else if chairWinner != "" {
+			decision.Winner, decision.TieBroken = chairWinner, TieBreakChair
+		} else if winner != "" {
+			decision.Winner, decision.TieBroken = winner, TieBreakScores
+		} else {
+			decision.Winner, decision.TieBroken = "opp", TieBreakScores
+		}
+	}
+
+	majority := 0
+	for _, set := range sets {
+		if set.Winner != decision.Winner {
+			continue
+		}
+		majority++
+		ironSeen := make(map[uint]bool)
+		for _, ballot := range set.Ballots {
 			if ballot.TeamRole == "gov" {
-				oldGovScore += ballot.Score
+				decision.GovTotal += ballot.Score
 			} else if ballot.TeamRole == "opp" {
-				oldOppScore += ballot.Score
+				decision.OppTotal += ballot.Score
+			}
+			// Reply dihitung di total tim, tapi tidak masuk tab speaker
+			if ballot.SpeakerID == 0 {
+				continue
 			}
+			if ballot.IsReply {
+				decision.ReplyScores[ballot.SpeakerID] += ballot.Score
+				continue
+			}
+			if !ballot.IsIronPerson || settings.IronPersonTab == IronPersonBoth {
+				decision.SpeakerScores[ballot.SpeakerID] += ballot.Score
+				continue
+			}
+			// Iron-person: posisi pertama saja, atau skor tertinggi dari dua posisi
+			if !ironSeen[ballot.SpeakerID] {
+				ironSeen[ballot.SpeakerID] = true
+				decision.SpeakerScores[ballot.SpeakerID] += ballot.Score
+			} else if settings.IronPersonTab == IronPersonHighest {
+				decision.SpeakerScores[ballot.SpeakerID] += math.Max(0, ballot.Score-firstIronScore(set.Ballots, ballot.SpeakerID))
+			}
+		}
+	}
+	if majority > 0 {
+		decision.GovTotal /= float64(majority)
+		decision.OppTotal /= float64(majority)
+		for id := range decision.SpeakerScores {
+			decision.SpeakerScores[id] /= float64(majority)
+		}
+		for id := range decision.ReplyScores {
+			decision.ReplyScores[id] /= float64(majority)
 		}
+	}
+	decision.Complete = decision.Received >= decision.Required
+	return decision
+}
 
-		// Revert speaker scores
-		for _, ballot := range oldBallots {
-			if ballot.SpeakerID != 0 {
-				var speaker models.Speaker
-				if err := tx.First(&speaker, ballot.SpeakerID).Error; err == nil {
-					speaker.TotalScore -= ballot.Score
-					tx.Save(&speaker)
-				}
-			}
+// firstIronScore: skor posisi pertama speaker iron-person di satu ballot set
+func firstIronScore(ballots []models.Ballot, speakerID uint) float64 {
+	for _, ballot := range ballots {
+		if ballot.SpeakerID == speakerID && ballot.IsIronPerson {
+			return ballot.Score
 		}
+	}
+	return 0
+}
 
-		// Revert team stats
-		if match.GovTeam.ID != 0 {
-			match.GovTeam.TotalSpeaker -= oldGovScore
-			if match.WinnerID != nil && *match.WinnerID == match.GovTeam.ID {
-				match.GovTeam.TotalVP -= 1
-				match.GovTeam.Wins -= 1
-			} else {
-				match.GovTeam.Losses -= 1
-			}
-			tx.Save(&match.GovTeam)
-		}
-		if match.OppTeam.ID != 0 {
-			match.OppTeam.TotalSpeaker -= oldOppScore
-			if match.WinnerID != nil && *match.WinnerID == match.OppTeam.ID {
-				match.OppTeam.TotalVP -= 1
-				match.OppTeam.Wins -= 1
-			} else {
-				match.OppTeam.Losses -= 1
-			}
-			tx.Save(&match.OppTeam)
+// loadMatchDecision memuat ballot set resmi (non-trainee, sudah dikonfirmasi) dari juri yang
+// masih di panel match, lalu menghitung keputusan panel. Ballot juri yang sudah dikeluarkan
+// dari panel tetap tersimpan tetapi tidak dihitung.
+func loadMatchDecision(db *gorm.DB, match models.Match, settings models.TournamentSettings) (panelDecision, error) {
+	voters, chairID, err := votingPanel(db, match)
+	if err != nil {
+		return panelDecision{}, err
+	}
+	panel := func(query *gorm.DB) *gorm.DB {
+		if len(voters) == 0 {
+			return query
 		}
+		ids := make([]uint, 0, len(voters))
+		for id := range voters {
+			ids = append(ids, id)
+		}
+		return query.Where("adjudicator_id IN ?", ids)
+	}
+	var sets []models.BallotSet
+	byID := func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }
+	if err := panel(db.Preload("Ballots", byID).Where("match_id = ? AND is_trainee = ? AND status = ?", match.ID, false, BallotConfirmed)).
+		Order("id asc").Find(&sets).Error; err != nil {
+		return panelDecision{}, err
+	}
+	decision := decidePanel(sets, len(voters), chairID, settings)
+	var pending int64
+	panel(db.Model(&models.BallotSet{}).Where("match_id = ? AND is_trainee = ? AND status = ?", match.ID, false, BallotSubmitted)).Count(&pending)
+	decision.Pending = int(pending)
+	return decision, nil
+}
 
-		// Hapus ballot lama
-		tx.Where("match_id = ?", input.MatchID).Delete(&models.Ballot{})
+// lockMatch memuat match beserta ronde-nya dan mengunci baris match (SELECT ... FOR UPDATE)
+// sampai transaksi selesai, supaya ballot panel yang masuk bersamaan dihitung berurutan
+func lockMatch(tx *gorm.DB, match *models.Match, id interface{}) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(match, id).Error; err != nil {
+		return err
+	}
+	var round models.Round
+	if tx.Limit(1).Find(&round, match.RoundID).RowsAffected > 0 {
+		match.Round = &round
 	}
+	return nil
+}
 
-	// 1. Simpan Skor Individu
-	var totalGov int = 0
-	var totalOpp int = 0
+// applyMatchDecision menghitung ulang keputusan panel, memperbarui status, pemenang & versi ballot
+// match yang berlaku, lalu menghitung ulang klasemen turnamen
+func applyMatchDecision(tx *gorm.DB, match *models.Match, tournamentID uint) (panelDecision, error) {
+	settings, err := loadTournamentSettings(tx, tournamentID)
+	if err != nil {
+		return panelDecision{}, err
+	}
+	decision, err := loadMatchDecision(tx, *match, settings)
+	if err != nil {
+		return panelDecision{}, err
+	}
 
-	// Track speaker IDs and their scores for later update
-	speakerScores := make(map[uint]int)
+	match.IsCompleted = decision.Complete
+	match.WinnerID = nil
+	if decision.Complete {
+		if decision.Winner == "gov" {
+			match.WinnerID = match.GovTeamID
+		} else {
+			match.WinnerID = match.OppTeamID
+		}
+	}
+	if err := tx.Model(&models.BallotSet{}).Where("match_id = ? AND status = ? AND is_trainee = ?", match.ID, BallotConfirmed, false).
+		Select("COALESCE(MAX(revision), 0)").Scan(&match.CurrentBallotRevision).Error; err != nil {
+		return decision, err
+	}
+	// Baris match sudah dikunci pemanggil; versi tetap dicek agar tidak menimpa edit lain
+	updated, err := updateVersioned(tx, match, match.Version, map[string]interface{}{
+		"is_completed":            match.IsCompleted,
+		"winner_id":               match.WinnerID,
+		"current_ballot_revision": match.CurrentBallotRevision,
+	})
+	if err != nil {
+		return decision, err
+	}
+	if !updated {
+		return decision, errStaleVersion
+	}
+	match.Version++
+	if _, err := recalculateStandings(tx, tournamentID); err != nil {
+		return decision, err
+	}
+	return decision, nil
+}
 
-	for _, ballot := range input.Scores {
-		ballot.MatchID = input.MatchID
+// resolveBallotSpeaker mencari speaker ballot di tim sesuai TeamRole (termasuk substitute yang
+// terdaftar). Speaker yang bukan anggota tim ditolak, tidak dibuat otomatis.
+func resolveBallotSpeaker(tx *gorm.DB, match models.Match, ballot models.Ballot) (models.Speaker, string) {
+	var teamID *uint
+	switch ballot.TeamRole {
+	case "gov":
+		teamID = match.GovTeamID
+	case "opp":
+		teamID = match.OppTeamID
+	default:
+		return models.Speaker{}, "TeamRole harus 'gov' atau 'opp'"
+	}
+	if teamID == nil || *teamID == 0 {
+		return models.Speaker{}, "Match tidak memiliki tim untuk role " + ballot.TeamRole
+	}
+
+	var speaker models.Speaker
+	if ballot.SpeakerID != 0 {
+		if err := tx.Where("id = ? AND team_id = ?", ballot.SpeakerID, *teamID).First(&speaker).Error; err != nil {
+			return speaker, fmt.Sprintf("Speaker %d bukan anggota tim %s", ballot.SpeakerID, ballot.TeamRole)
+		}
+		return speaker, ""
+	}
+	if ballot.Speaker.Name == "" {
+		return speaker, "SpeakerID atau Speaker.Name harus diisi"
+	}
+	if err := tx.Where("LOWER(name) = ? AND team_id = ?", strings.ToLower(strings.TrimSpace(ballot.Speaker.Name)), *teamID).
+		First(&speaker).Error; err != nil {
+		return speaker, fmt.Sprintf("Speaker '%s' bukan anggota tim %s", ballot.Speaker.Name, ballot.TeamRole)
+	}
+	return speaker, ""
+}
 
-		// Validasi data pembicara (Jaga-jaga)
-		if ballot.Speaker.Name == "" {
+// markIronPersons menandai pidato substantif dari speaker yang mengisi dua posisi (iron-person).
+// Mengembalikan pesan error jika ada speaker yang mengisi lebih dari dua posisi.
+func markIronPersons(ballots []models.Ballot) string {
+	speeches := make(map[uint]int)
+	for _, ballot := range ballots {
+		if !ballot.IsReply {
+			speeches[ballot.SpeakerID]++
+		}
+	}
+	for i := range ballots {
+		count := speeches[ballots[i].SpeakerID]
+		if count > 2 {
+			return fmt.Sprintf("Speaker %d mengisi %d posisi (maksimal 2)", ballots[i].SpeakerID, count)
+		}
+		ballots[i].IsIronPerson = !ballots[i].IsReply && count == 2
+	}
+	return ""
+}
+
+// commitBallotSubmission mencatat hasil untuk submission_id (agar bisa di-replay) lalu commit
+func commitBallotSubmission(tx *gorm.DB, input BallotInput, setID uint, body gin.H) (int, gin.H) {
+	if input.SubmissionID != "" {
+		response, err := json.Marshal(body)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Nama speaker tidak boleh kosong"})
-			return
+			return http.StatusInternalServerError, gin.H{"error": err.Error()}
 		}
+		record := models.BallotSubmission{SubmissionID: input.SubmissionID, MatchID: input.MatchID,
+			AdjudicatorID: input.AdjudicatorID, BallotSetID: setID, Response: string(response)}
+		if err := tx.Create(&record).Error; err != nil {
+			tx.Rollback()
+			return http.StatusConflict, gin.H{"error": "submission_id sedang diproses, kirim ulang untuk mendapatkan hasilnya"}
+		}
+	}
+	tx.Commit()
+	return http.StatusOK, body
+}
 
-		// Tentukan TeamID berdasarkan TeamRole dan MatchID
-		var teamID uint
-		if ballot.TeamRole == "gov" {
-			if match.GovTeamID == nil {
-				tx.Rollback()
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Match tidak memiliki Government Team"})
-				return
+// SubmitBallot menyimpan ballot set satu juri. Jika turnamen mewajibkan konfirmasi, ballot
+// berstatus "submitted" sampai dikonfirmasi user lain; selain itu langsung menggantikan ballot
+// juri itu sebelumnya.
+// Ballot juri lain di panel tetap tersimpan; hasil match ditentukan mayoritas panel dan
+// match baru selesai setelah jumlah ballot yang dibutuhkan masuk.
+func SubmitBallot(c *gin.Context) {
+	var input BallotInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var submittedBy *uint
+	if userID, ok := currentUserID(c); ok {
+		submittedBy = &userID
+	}
+	c.JSON(submitBallot(input, submittedBy))
+}
+
+// batchBallotResult: hasil satu ballot dalam batch offline
+type batchBallotResult struct {
+	Index        int    `json:"index"`
+	SubmissionID string `json:"submission_id,omitempty"`
+	Status       string `json:"status"` // "ok", "replayed", "conflict", "error"
+	HTTPStatus   int    `json:"http_status"`
+	Result       gin.H  `json:"result"`
+}
+
+// POST /api/ballots/batch
+// Antrean ballot yang dicatat offline ({"ballots": [...]}), diproses berurutan. Setiap item
+// punya hasil sendiri; item yang gagal atau konflik tidak menghentikan item berikutnya.
+func SubmitBallotBatch(c *gin.Context) {
+	var input struct {
+		Ballots []BallotInput `json:"ballots" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var submittedBy *uint
+	if userID, ok := currentUserID(c); ok {
+		submittedBy = &userID
+	}
+	results := make([]batchBallotResult, 0, len(input.Ballots))
+	summary := map[string]int{"ok": 0, "replayed": 0, "conflict": 0, "error": 0}
+	for i, ballot := range input.Ballots {
+		code, body := submitBallot(ballot, submittedBy)
+		result := batchBallotResult{Index: i, SubmissionID: ballot.SubmissionID, HTTPStatus: code, Result: body}
+		switch {
+		case code == http.StatusOK && body["replayed"] == true:
+			result.Status = "replayed"
+		case code == http.StatusOK:
+			result.Status = "ok"
+		case code == http.StatusConflict:
+			result.Status = "conflict"
+		default:
+			result.Status = "error"
+		}
+		summary[result.Status]++
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results, "summary": summary})
+}
+
+// submitBallot memproses satu ballot (dipakai SubmitBallot & batch offline) dan mengembalikan
+// status HTTP beserta body respons
+func submitBallot(input BallotInput, submittedBy *uint) (int, gin.H) {
+	expandReplies(&input)
+
+	// submission_id yang sama: kembalikan hasil submit pertama, tanpa memproses ulang
+	if input.SubmissionID != "" {
+		var previous models.BallotSubmission
+		if models.DB.Where("submission_id = ?", input.SubmissionID).Limit(1).Find(&previous).RowsAffected > 0 {
+			if previous.MatchID != input.MatchID || previous.AdjudicatorID != input.AdjudicatorID {
+				return http.StatusConflict, gin.H{"error": "submission_id sudah dipakai untuk ballot lain"}
 			}
-			teamID = *match.GovTeamID
-		} else if ballot.TeamRole == "opp" {
-			if match.OppTeamID == nil {
-				tx.Rollback()
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Match tidak memiliki Opposition Team"})
-				return
+			response := gin.H{}
+			if err := json.Unmarshal([]byte(previous.Response), &response); err != nil {
+				return http.StatusInternalServerError, gin.H{"error": err.Error()}
 			}
-			teamID = *match.OppTeamID
-		} else {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "TeamRole harus 'gov' atau 'opp'"})
-			return
+			response["replayed"] = true
+			return http.StatusOK, response
 		}
+	}
 
-		// Debug log
-		fmt.Printf("Debug: TeamRole=%s, TeamID=%d, MatchID=%d, SpeakerID=%d, Score=%d\n", ballot.TeamRole, teamID, ballot.MatchID, ballot.SpeakerID, ballot.Score)
+	// Mulai Transaksi Database (Biar Aman)
+	tx := models.DB.Begin()
 
-		// Validasi TeamID tidak boleh 0
-		if teamID == 0 {
+	var match models.Match
+	if err := lockMatch(tx, &match, input.MatchID); err != nil {
+		tx.Rollback()
+		return http.StatusNotFound, gin.H{"error": "Match tidak ditemukan"}
+	}
+	if match.IsBye {
+		tx.Rollback()
+		return http.StatusBadRequest, gin.H{"error": "Match bye tidak memerlukan ballot"}
+	}
+	if match.IsForfeit {
+		tx.Rollback()
+		return http.StatusBadRequest, gin.H{"error": "Match forfeit tidak memerlukan ballot"}
+	}
+
+	// Juri mengganti ballot-nya sendiri yang sudah dihitung: versi match wajib dan harus masih sama
+	// (baris match terkunci lewat lockMatch). Ballot pertama tiap juri panel tidak perlu versi,
+	// supaya chair & wing yang memuat draw yang sama tetap bisa submit berurutan.
+	if !input.Draft {
+		var counted int64
+		if err := tx.Model(&models.BallotSet{}).Where("match_id = ? AND adjudicator_id = ? AND status = ?",
+			match.ID, input.AdjudicatorID, BallotConfirmed).Count(&counted).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "TeamID tidak valid (0)"})
-			return
+			return http.StatusInternalServerError, gin.H{"error": err.Error()}
 		}
-
-		// Cari atau buat speaker baru jika belum ada
-		var speaker models.Speaker
-		var speakerID uint
-
-		if ballot.SpeakerID != 0 {
-			// Jika SpeakerID sudah ada, gunakan yang ada
-			if err := tx.First(&speaker, ballot.SpeakerID).Error; err != nil {
+		if counted > 0 {
+			if input.MatchVersion == nil {
 				tx.Rollback()
-				c.JSON(http.StatusNotFound, gin.H{"error": "Speaker tidak ditemukan"})
-				return
+				return http.StatusPreconditionRequired, gin.H{"error": "match_version is required to replace a counted ballot (kirim versi match yang diedit)"}
 			}
-			speakerID = speaker.ID
-		} else if ballot.Speaker.Name != "" {
-			// Cari speaker berdasarkan nama dan tim
-			err := tx.Where("name = ? AND team_id = ?", ballot.Speaker.Name, teamID).First(&speaker).Error
-			if err != nil {
-				// Jika tidak ditemukan, buat speaker baru
-				speaker = models.Speaker{
-					Name:   ballot.Speaker.Name,
-					TeamID: teamID,
-				}
-				if err := tx.Create(&speaker).Error; err != nil {
-					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat speaker: " + err.Error()})
-					return
-				}
+			if *input.MatchVersion != match.Version {
+				tx.Rollback()
+				return http.StatusConflict, gin.H{"error": "Data sudah diubah user lain, muat ulang lalu ulangi", "current": match}
 			}
-			speakerID = speaker.ID
-		} else {
+		}
+	}
+
+	// Ballot juri ini sudah berubah sejak dicatat di perangkat (mis. diinput tab room);
+	// ballot juri lain di panel tidak dihitung sebagai konflik
+	if input.BaseRevision != nil {
+		var latest models.BallotSet
+		tx.Where("match_id = ? AND adjudicator_id = ? AND status <> ?", match.ID, input.AdjudicatorID, BallotDraft).
+			Order("revision desc").Limit(1).Find(&latest)
+		if latest.Revision != *input.BaseRevision {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "SpeakerID atau Speaker.Name harus diisi"})
-			return
+			return http.StatusConflict, gin.H{
+				"error":            "Ballot juri ini sudah berubah sejak dicatat",
+				"current_revision": latest.Revision,
+				"ballot_set":       latest,
+				"current":          match,
+			}
 		}
+	}
+
+	var tournamentID uint
+	if match.Round != nil {
+		tournamentID = match.Round.TournamentID
+	}
+	settings, err := loadTournamentSettings(tx, tournamentID)
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
+	}
 
-		// Track speaker score for later update
-		speakerScores[speakerID] += ballot.Score
-		fmt.Printf("Debug: Tracked speaker %d with score %d (total: %d)\n", speakerID, ballot.Score, speakerScores[speakerID])
+	// Validasi skor sesuai format & settings (draft boleh belum lengkap)
+	if !input.Draft {
+		var tournament models.Tournament
+		tx.Select("format").First(&tournament, tournamentID)
+		if violations := validateBallot(scoreRulesFor(tournament.Format, settings), input); len(violations) > 0 {
+			tx.Rollback()
+			return http.StatusBadRequest, gin.H{"error": "Ballot tidak valid", "violations": violations}
+		}
+	}
 
-		// Simpan ballot dengan SpeakerID yang valid
-		ballotToSave := models.Ballot{
-			MatchID:       ballot.MatchID,
+	// Juri trainee: ballot disimpan untuk perbandingan, hasil match tidak berubah
+	isTrainee := isTraineeOnMatch(tx, match.ID, input.AdjudicatorID)
+	voters, _, err := votingPanel(tx, match)
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
+	}
+	if !isTrainee && len(voters) > 0 && !voters[input.AdjudicatorID] {
+		tx.Rollback()
+		return http.StatusBadRequest, gin.H{"error": "Juri tidak terdaftar di panel match ini"}
+	}
+
+	// Status awal: draft, menunggu konfirmasi user lain, atau langsung dihitung
+	status := BallotConfirmed
+	if input.Draft {
+		status = BallotDraft
+	} else if !isTrainee && settings.RequireBallotConfirmation {
+		status = BallotSubmitted
+	}
+
+	// Draft juri ini sebelumnya selalu diganti
+	if err := deleteBallotSets(tx, match.ID, input.AdjudicatorID, isTrainee, BallotDraft); err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
+	}
+
+	set := models.BallotSet{MatchID: match.ID, AdjudicatorID: input.AdjudicatorID, IsTrainee: isTrainee, Status: status}
+	set.SubmittedByID = submittedBy
+	if status != BallotDraft {
+		if set.Revision, err = nextBallotRevision(tx, match.ID); err != nil {
+			tx.Rollback()
+			return http.StatusInternalServerError, gin.H{"error": err.Error()}
+		}
+	}
+	// 1. Speaker harus anggota tim (atau substitute terdaftar); satu speaker boleh dua posisi
+	records := make([]models.Ballot, 0, len(input.Scores))
+	for _, ballot := range input.Scores {
+		speaker, msg := resolveBallotSpeaker(tx, match, ballot)
+		if msg != "" {
+			tx.Rollback()
+			return http.StatusBadRequest, gin.H{"error": msg}
+		}
+		records = append(records, models.Ballot{
+			MatchID:       match.ID,
 			AdjudicatorID: input.AdjudicatorID,
-			SpeakerID:     speakerID,
+			SpeakerID:     speaker.ID,
 			Score:         ballot.Score,
 			Position:      ballot.Position,
 			IsReply:       ballot.IsReply,
 			TeamRole:      ballot.TeamRole,
 			Winner:        input.Winner,
-		}
+			IsTrainee:     isTrainee,
+		})
+	}
+	if msg := markIronPersons(records); msg != "" {
+		tx.Rollback()
+		return http.StatusBadRequest, gin.H{"error": msg}
+	}
+
+	if err := tx.Create(&set).Error; err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, gin.H{"error": "Gagal simpan ballot: " + err.Error()}
+	}
 
-		if err := tx.Create(&ballotToSave).Error; err != nil {
+	// 2. Simpan Skor Individu
+	for _, record := range records {
+		record.BallotSetID = set.ID
+		if err := tx.Create(&record).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan skor: " + err.Error()})
-			return
+			return http.StatusInternalServerError, gin.H{"error": "Gagal simpan skor: " + err.Error()}
 		}
+		set.Ballots = append(set.Ballots, record)
 
-		// Hitung Total Skor
-		if ballot.TeamRole == "gov" {
-			totalGov += ballot.Score
-		} else if ballot.TeamRole == "opp" {
-			totalOpp += ballot.Score
+		if record.TeamRole == "gov" {
+			set.GovTotal += record.Score
+		} else {
+			set.OppTotal += record.Score
 		}
 	}
 
-	// 2. Tentukan Pemenang (Logic Asian Parliamentary)
-	// Match sudah diambil di atas, tidak perlu diambil lagi
-
-	// Update Status Match
-	match.IsCompleted = true
-	// match.Adjudicator = input.Adjudicator // Removed because Adjudicator is now a relation
+	// 3. Keputusan juri ini: pilihan manual, kalau tidak ada pakai skor tertinggi
+	set.Winner = ballotSetWinner(input.Winner, set.GovTotal, set.OppTotal)
+	if err := tx.Model(&set).Select("winner", "gov_total", "opp_total").Updates(&set).Error; err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, gin.H{"error": "Gagal simpan ballot: " + err.Error()}
+	}
 
-	// Logika Penentuan Pemenang
-	// Prioritas: Gunakan winner yang dipilih manual oleh adjudicator
-	// Kalau tidak ada, gunakan skor tertinggi
-	if input.Winner == "gov" {
-		match.WinnerID = match.GovTeamID
-	} else if input.Winner == "opp" {
-		match.WinnerID = match.OppTeamID
-	} else if totalGov > totalOpp {
-		// Fallback ke skor jika winner tidak diset
-		match.WinnerID = match.GovTeamID
-	} else {
-		match.WinnerID = match.OppTeamID
+	switch status {
+	case BallotDraft:
+		return commitBallotSubmission(tx, input, set.ID, gin.H{"message": "Ballot disimpan sebagai draft", "ballot_set_id": set.ID, "status": set.Status})
+	case BallotSubmitted:
+		// Double entry: bandingkan dengan entri lain dari kertas ballot yang sama
+		discrepancies := []ballotDiscrepancy{}
+		var previous models.BallotSet
+		if tx.Preload("Ballots").Where("match_id = ? AND adjudicator_id = ? AND is_trainee = ? AND status = ? AND id <> ?",
+			match.ID, input.AdjudicatorID, isTrainee, BallotSubmitted, set.ID).
+			Order("id desc").Limit(1).Find(&previous).RowsAffected > 0 {
+			discrepancies = compareBallotSets(previous, set)
+		}
+		return commitBallotSubmission(tx, input, set.ID, gin.H{
+			"message":       "Ballot disimpan, menunggu konfirmasi",
+			"ballot_set_id": set.ID,
+			"status":        set.Status,
+			"total_gov":     set.GovTotal,
+			"total_opp":     set.OppTotal,
+			"discrepancies": discrepancies,
+		})
 	}
 
-	if err := tx.Save(&match).Error; err != nil {
+	// Ballot langsung dihitung: entri lain juri ini tidak berlaku lagi
+	if err := supersedeBallotSets(tx, set); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update hasil match"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
 	}
 
-	// 3. Update Klasemen Tim (Standings)
-	// Tambahkan VP (Victory Point) dan Speaker Score ke masing-masing tim
-
-	// Update Gov Team
-	match.GovTeam.TotalSpeaker += totalGov
-	if match.WinnerID == match.GovTeamID {
-		match.GovTeam.TotalVP += 1 // Menang dapat 1 poin
-		match.GovTeam.Wins += 1
-	} else {
-		match.GovTeam.Losses += 1
+	if isTrainee {
+		return commitBallotSubmission(tx, input, set.ID, gin.H{
+			"message":    "Ballot trainee disimpan (tidak dihitung dalam hasil)",
+			"is_trainee": true,
+			"total_gov":  set.GovTotal,
+			"total_opp":  set.OppTotal,
+		})
 	}
-	if err := tx.Save(&match.GovTeam).Error; err != nil {
+
+	// 4. Keputusan panel, status match & klasemen
+	decision, err := applyMatchDecision(tx, &match, tournamentID)
+	if err != nil {
 		tx.Rollback()
-		return
+		return versionErrorStatus(err), gin.H{"error": err.Error()}
 	}
 
-	// Update Opp Team
-	match.OppTeam.TotalSpeaker += totalOpp
-	if match.WinnerID == match.OppTeamID {
-		match.OppTeam.TotalVP += 1
-		match.OppTeam.Wins += 1
-	} else {
-		match.OppTeam.Losses += 1
+	// Selesai!
+	message := "Skor disimpan & Pemenang ditentukan!"
+	if !decision.Complete {
+		message = fmt.Sprintf("Skor disimpan, menunggu ballot juri lain (%d/%d)", decision.Received, decision.Required)
 	}
-	if err := tx.Save(&match.OppTeam).Error; err != nil {
-		tx.Rollback()
+	return commitBallotSubmission(tx, input, set.ID, gin.H{
+		"message":       message,
+		"ballot_set_id": set.ID,
+		"status":        set.Status,
+		"winner_id":     match.WinnerID,
+		"total_gov":     set.GovTotal,
+		"total_opp":     set.OppTotal,
+		"decision":      decision,
+	})
+}
+
+// GET /api/matches/:id/ballots
+// Ballot set semua juri di match ini beserta keputusan panel
+func GetMatchBallotSets(c *gin.Context) {
+	var match models.Match
+	if err := models.DB.Preload("Round").First(&match, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
 		return
 	}
 
-	// 4. Update Speaker Individual Scores (untuk Speaker Standings)
-	fmt.Printf("Debug: Updating %d speakers scores\n", len(speakerScores))
-	for speakerID, score := range speakerScores {
-		if speakerID != 0 {
-			var speaker models.Speaker
-			if err := tx.First(&speaker, speakerID).Error; err == nil {
-				oldScore := speaker.TotalScore
-				speaker.TotalScore += score
-				if err := tx.Save(&speaker).Error; err != nil {
-					fmt.Printf("Warning: Failed to update speaker %d score: %v\n", speakerID, err)
-				} else {
-					fmt.Printf("Debug: Updated speaker %d score from %d to %d\n", speakerID, oldScore, speaker.TotalScore)
-				}
-			} else {
-				fmt.Printf("Warning: Speaker %d not found: %v\n", speakerID, err)
-			}
-		}
+	var sets []models.BallotSet
+	if err := models.DB.Preload("Adjudicator").Preload("Ballots").Preload("Ballots.Speaker").
+		Where("match_id = ?", match.ID).Order("is_trainee asc, id asc").Find(&sets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if sets == nil {
+		sets = []models.BallotSet{}
 	}
 
-	// Selesai!
-	tx.Commit()
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Skor disimpan & Pemenang ditentukan!",
-		"winner_id": match.WinnerID,
-		"total_gov": totalGov,
-		"total_opp": totalOpp,
-	})
+	var tournamentID uint
+	if match.Round != nil {
+		tournamentID = match.Round.TournamentID
+	}
+	settings, err := loadTournamentSettings(models.DB, tournamentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	decision, err := loadMatchDecision(models.DB, match, settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sets, "decision": decision})
 }
 
 func GetBallots(c *gin.Context) {
@@ -295,7 +732,7 @@ func GetBallots(c *gin.Context) {
 
 	if matchID != "" {
 		// Query by specific match
-		if err := models.DB.Preload("Speaker").Preload("Adjudicator").Where("match_id = ?", matchID).Find(&ballots).Error; err != nil {
+		if err := models.DB.Preload("Speaker").Preload("Adjudicator").Where("match_id = ?", matchID).Where(countedBallotsClause, BallotConfirmed).Find(&ballots).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -313,7 +750,7 @@ func GetBallots(c *gin.Context) {
 			return
 		}
 
-		if err := models.DB.Preload("Speaker").Preload("Adjudicator").Where("match_id IN ?", matchIDs).Find(&ballots).Error; err != nil {
+		if err := models.DB.Preload("Speaker").Preload("Adjudicator").Where("match_id IN ?", matchIDs).Where(countedBallotsClause, BallotConfirmed).Find(&ballots).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
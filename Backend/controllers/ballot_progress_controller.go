@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Status ballot per match di dashboard progres ronde
+const (
+	ProgressNone        = "none"         // Belum ada ballot masuk
+	ProgressPartial     = "partial"      // Sebagian panel sudah submit
+	ProgressSubmitted   = "submitted"    // Ada ballot yang menunggu konfirmasi
+	ProgressConfirmed   = "confirmed"    // Hasil match sudah lengkap
+	ProgressDisputed    = "disputed"     // Entri ganda ballot yang sama saling berbeda
+	ProgressNotRequired = "not_required" // Bye / forfeit
+)
+
+// missingAdjudicator: juri panel yang belum mengirim ballot
+type missingAdjudicator struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// matchProgress: status ballot satu match
+type matchProgress struct {
+	MatchID   uint                 `json:"match_id"`
+	Room      string               `json:"room"`
+	GovTeam   string               `json:"gov_team"`
+	OppTeam   string               `json:"opp_team"`
+	Status    string               `json:"status"`
+	Received  int                  `json:"received"` // Ballot yang sudah dihitung (confirmed)
+	Required  int                  `json:"required"`
+	Pending   int                  `json:"pending"` // Ballot submitted menunggu konfirmasi
+	Missing   []missingAdjudicator `json:"missing_adjudicators"`
+	Disputes  []ballotDiscrepancy  `json:"disputes,omitempty"`
+	Completed bool                 `json:"is_completed"`
+}
+
+// ballotProgressFor menghitung status ballot satu match dari ballot set yang masuk
+func ballotProgressFor(db *gorm.DB, match models.Match, settings models.TournamentSettings) (matchProgress, error) {
+	progress := matchProgress{MatchID: match.ID, Completed: match.IsCompleted, Missing: []missingAdjudicator{}}
+	if match.Room != nil {
+		progress.Room = match.Room.Name
+	}
+	if match.GovTeam != nil {
+		progress.GovTeam = match.GovTeam.Name
+	}
+	if match.OppTeam != nil {
+		progress.OppTeam = match.OppTeam.Name
+	}
+	if match.IsBye || match.IsForfeit {
+		progress.Status = ProgressNotRequired
+		return progress, nil
+	}
+
+	decision, err := loadMatchDecision(db, match, settings)
+	if err != nil {
+		return progress, err
+	}
+	progress.Received, progress.Required, progress.Pending = decision.Received, decision.Required, decision.Pending
+
+	var sets []models.BallotSet
+	if err := db.Preload("Ballots").
+		Where("match_id = ? AND is_trainee = ? AND status IN ?", match.ID, false, []string{BallotSubmitted, BallotConfirmed}).
+		Order("id asc").Find(&sets).Error; err != nil {
+		return progress, err
+	}
+	submitted := make(map[uint]bool)
+	pendingByAdj := make(map[uint][]models.BallotSet)
+	for _, set := range sets {
+		submitted[set.AdjudicatorID] = true
+		if set.Status == BallotSubmitted {
+			pendingByAdj[set.AdjudicatorID] = append(pendingByAdj[set.AdjudicatorID], set)
+		}
+	}
+	for _, entries := range pendingByAdj {
+		for i := 1; i < len(entries); i++ {
+			progress.Disputes = append(progress.Disputes, compareBallotSets(entries[i-1], entries[i])...)
+		}
+	}
+
+	// Juri panel (chair + panellist) yang belum submit; match tanpa panel pakai chair lama
+	if len(match.Panel) > 0 {
+		for _, member := range match.Panel {
+			if member.Role == PanelRoleTrainee || submitted[member.AdjudicatorID] {
+				continue
+			}
+			progress.Missing = append(progress.Missing, missingAdjudicator{ID: member.AdjudicatorID, Name: member.Adjudicator.Name, Role: member.Role})
+		}
+	} else if match.Adjudicator != nil && !submitted[match.Adjudicator.ID] {
+		progress.Missing = append(progress.Missing, missingAdjudicator{ID: match.Adjudicator.ID, Name: match.Adjudicator.Name, Role: PanelRoleChair})
+	}
+
+	switch {
+	case len(progress.Disputes) > 0:
+		progress.Status = ProgressDisputed
+	case decision.Complete:
+		progress.Status = ProgressConfirmed
+	case decision.Pending > 0:
+		progress.Status = ProgressSubmitted
+	case decision.Received > 0:
+		progress.Status = ProgressPartial
+	default:
+		progress.Status = ProgressNone
+	}
+	return progress, nil
+}
+
+// GET /api/rounds/:id/ballot-progress
+// Dashboard tab room: status ballot tiap match, juri yang belum submit, dan rekap ronde
+func GetRoundBallotProgress(c *gin.Context) {
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	settings, err := loadTournamentSettings(models.DB, round.TournamentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var matches []models.Match
+	if err := models.DB.Preload("Room").Preload("GovTeam").Preload("OppTeam").Preload("Adjudicator").
+		Preload("Panel", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).Preload("Panel.Adjudicator").
+		Where("round_id = ?", round.ID).Order("id asc").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	progress := []matchProgress{}
+	counts := map[string]int{
+		ProgressNone: 0, ProgressPartial: 0, ProgressSubmitted: 0,
+		ProgressConfirmed: 0, ProgressDisputed: 0, ProgressNotRequired: 0,
+	}
+	outstanding := 0
+	for _, match := range matches {
+		entry, err := ballotProgressFor(models.DB, match, settings)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		counts[entry.Status]++
+		if entry.Status != ProgressConfirmed && entry.Status != ProgressNotRequired {
+			outstanding++
+		}
+		progress = append(progress, entry)
+	}
+
+	var sinceRelease *int64
+	if round.DrawReleasedAt != nil {
+		seconds := int64(time.Since(*round.DrawReleasedAt).Seconds())
+		sinceRelease = &seconds
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": progress,
+		"round": gin.H{
+			"id":                    round.ID,
+			"name":                  round.Name,
+			"draw_status":           round.DrawStatus,
+			"draw_released_at":      round.DrawReleasedAt,
+			"seconds_since_release": sinceRelease,
+			"total_matches":         len(matches),
+			"outstanding":           outstanding,
+			"counts":                counts,
+		},
+	})
+}
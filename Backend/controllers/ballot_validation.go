@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/star_fj/eds-backend/models"
+)
+
+// scoreRules: aturan skor ballot sebuah turnamen (bawaan format, bisa diubah di settings)
+type scoreRules struct {
+	SpeakerMin        float64 `json:"speaker_min"`
+	SpeakerMax        float64 `json:"speaker_max"`
+	ReplyMin          float64 `json:"reply_min"`
+	ReplyMax          float64 `json:"reply_max"` // 0 = format tanpa reply speech
+	Step              float64 `json:"step"`
+	SpeakersPerSide   int     `json:"speakers_per_side"`
+	AllowLowPointWins bool    `json:"allow_low_point_wins"`
+	AllowTiedScores   bool    `json:"allow_tied_scores"`
+}
+
+// ballotViolation: satu pelanggaran aturan skor pada ballot
+type ballotViolation struct {
+	Field    string `json:"field"` // "score", "step", "speakers", "reply", "winner"
+	TeamRole string `json:"team_role,omitempty"`
+	Position string `json:"position,omitempty"`
+	Message  string `json:"message"`
+}
+
+// scoreRulesFor: bawaan format (AP 68-82 + reply 34-41, 3 speaker; BP 60-80, 2 speaker,
+// tanpa reply), lalu ditimpa settings turnamen yang tidak 0
+func scoreRulesFor(format string, settings models.TournamentSettings) scoreRules {
+	rules := scoreRules{SpeakerMin: 68, SpeakerMax: 82, ReplyMin: 34, ReplyMax: 41, Step: 1, SpeakersPerSide: 3}
+	if strings.EqualFold(format, "british") || strings.EqualFold(format, "BP") {
+		rules = scoreRules{SpeakerMin: 60, SpeakerMax: 80, Step: 1, SpeakersPerSide: 2}
+	}
+
+	if settings.SpeakerScoreMin > 0 {
+		rules.SpeakerMin = settings.SpeakerScoreMin
+	}
+	if settings.SpeakerScoreMax > 0 {
+		rules.SpeakerMax = settings.SpeakerScoreMax
+	}
+	if settings.ReplyScoreMin > 0 {
+		rules.ReplyMin = settings.ReplyScoreMin
+	}
+	if settings.ReplyScoreMax > 0 {
+		rules.ReplyMax = settings.ReplyScoreMax
+	}
+	if settings.ScoreStep > 0 {
+		rules.Step = settings.ScoreStep
+	}
+	if settings.SpeakersPerSide > 0 {
+		rules.SpeakersPerSide = settings.SpeakersPerSide
+	}
+	rules.AllowLowPointWins = settings.AllowLowPointWins
+	rules.AllowTiedScores = settings.AllowTiedScores
+	return rules
+}
+
+// validateBallot mengecek seluruh isian ballot dan mengembalikan semua pelanggaran sekaligus
+func validateBallot(rules scoreRules, input BallotInput) []ballotViolation {
+	violations := []ballotViolation{}
+	substantive := map[string]int{}
+	replies := map[string]int{}
+	totals := map[string]float64{}
+	// Speaker pertama & kedua tiap tim (dari posisinya, mis. PM/DPM, LO/DLO) yang boleh memberi reply
+	replyEligible := map[string]map[string]bool{"gov": {}, "opp": {}}
+	for _, ballot := range input.Scores {
+		eligible, ok := replyEligible[ballot.TeamRole]
+		if !ok || ballot.IsReply {
+			continue
+		}
+		for i, position := range speakerPositions(ballot.TeamRole, rules.SpeakersPerSide) {
+			if i < 2 && strings.EqualFold(strings.TrimSpace(ballot.Position), position) {
+				eligible[ballotSpeakerKey(ballot)] = true
+			}
+		}
+	}
+
+	for _, ballot := range input.Scores {
+		if ballot.TeamRole != "gov" && ballot.TeamRole != "opp" {
+			violations = append(violations, ballotViolation{Field: "team_role", Position: ballot.Position,
+				Message: fmt.Sprintf("team_role %q harus 'gov' atau 'opp'", ballot.TeamRole)})
+			continue
+		}
+		totals[ballot.TeamRole] += ballot.Score
+
+		min, max := rules.SpeakerMin, rules.SpeakerMax
+		if ballot.IsReply {
+			replies[ballot.TeamRole]++
+			if rules.ReplyMax == 0 {
+				violations = append(violations, ballotViolation{Field: "reply", TeamRole: ballot.TeamRole, Position: ballot.Position,
+					Message: "Format ini tidak memakai reply speech"})
+				continue
+			}
+			if !replyEligible[ballot.TeamRole][ballotSpeakerKey(ballot)] {
+				violations = append(violations, ballotViolation{Field: "reply", TeamRole: ballot.TeamRole, Position: ballot.Position,
+					Message: "Reply speech harus dari speaker pertama atau kedua tim"})
+			}
+			min, max = rules.ReplyMin, rules.ReplyMax
+		} else {
+			substantive[ballot.TeamRole]++
+		}
+
+		if ballot.Score < min || ballot.Score > max {
+			violations = append(violations, ballotViolation{Field: "score", TeamRole: ballot.TeamRole, Position: ballot.Position,
+				Message: fmt.Sprintf("Skor %g di luar rentang %g-%g", ballot.Score, min, max)})
+		}
+		if steps := ballot.Score / rules.Step; math.Abs(steps-math.Round(steps)) > 1e-9 {
+			violations = append(violations, ballotViolation{Field: "step", TeamRole: ballot.TeamRole, Position: ballot.Position,
+				Message: fmt.Sprintf("Skor %g harus kelipatan %g", ballot.Score, rules.Step)})
+		}
+	}
+
+	for _, role := range []string{"gov", "opp"} {
+		if substantive[role] != rules.SpeakersPerSide {
+			violations = append(violations, ballotViolation{Field: "speakers", TeamRole: role,
+				Message: fmt.Sprintf("Dibutuhkan %d speaker, diisi %d", rules.SpeakersPerSide, substantive[role])})
+		}
+		if replies[role] > 1 {
+			violations = append(violations, ballotViolation{Field: "reply", TeamRole: role,
+				Message: "Maksimal satu reply speech per tim"})
+		}
+	}
+
+	gov, opp := totals["gov"], totals["opp"]
+	if gov == opp && !rules.AllowTiedScores {
+		violations = append(violations, ballotViolation{Field: "winner",
+			Message: fmt.Sprintf("Total skor seri (%g); seri tidak diizinkan", gov)})
+	}
+	if !rules.AllowLowPointWins && gov != opp &&
+		((input.Winner == "gov" && gov < opp) || (input.Winner == "opp" && opp < gov)) {
+		violations = append(violations, ballotViolation{Field: "winner",
+			Message: fmt.Sprintf("Pemenang %s memiliki total skor lebih rendah (%g vs %g)", input.Winner, math.Min(gov, opp), math.Max(gov, opp))})
+	}
+	if input.Winner != "" && input.Winner != "gov" && input.Winner != "opp" {
+		violations = append(violations, ballotViolation{Field: "winner", Message: "winner harus 'gov' atau 'opp'"})
+	}
+	return violations
+}
+
+// ballotSpeakerKey: identitas speaker di ballot (ID, atau nama jika speaker diisi lewat nama)
+func ballotSpeakerKey(ballot models.Ballot) string {
+	if ballot.SpeakerID != 0 {
+		return fmt.Sprintf("id:%d", ballot.SpeakerID)
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(ballot.Speaker.Name))
+}
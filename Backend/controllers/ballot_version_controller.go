@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// nextBallotRevision: nomor versi berikutnya untuk ballot match ini. Dipanggil setelah baris
+// match dikunci (lockMatch) agar submit bersamaan tidak mendapat nomor yang sama; set yang
+// terhapus ikut dihitung karena nomornya tetap terpakai di unique index.
+func nextBallotRevision(tx *gorm.DB, matchID uint) (int, error) {
+	var max int
+	err := tx.Unscoped().Model(&models.BallotSet{}).Where("match_id = ?", matchID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&max).Error
+	return max + 1, err
+}
+
+// findBallotRevision memuat satu versi ballot match berdasarkan nomornya
+func findBallotRevision(db *gorm.DB, matchID uint, revision string) (models.BallotSet, bool) {
+	var set models.BallotSet
+	number, err := strconv.Atoi(revision)
+	if err != nil || number <= 0 {
+		return set, false
+	}
+	err = db.Preload("Ballots").Where("match_id = ? AND revision = ?", matchID, number).First(&set).Error
+	return set, err == nil
+}
+
+// GET /api/matches/:id/ballot-versions
+// Semua versi ballot match (tanpa draft), urut dari yang paling awal
+func GetBallotVersions(c *gin.Context) {
+	var match models.Match
+	if err := models.DB.First(&match, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+
+	var sets []models.BallotSet
+	if err := models.DB.Preload("Adjudicator").Preload("Ballots").Preload("Ballots.Speaker").
+		Where("match_id = ? AND revision > 0", match.ID).Order("revision asc").Find(&sets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if sets == nil {
+		sets = []models.BallotSet{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sets, "current_revision": match.CurrentBallotRevision})
+}
+
+// GET /api/matches/:id/ballot-versions/diff?from=1&to=2
+func DiffBallotVersions(c *gin.Context) {
+	var match models.Match
+	if err := models.DB.First(&match, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+	from, ok := findBallotRevision(models.DB, match.ID, c.Query("from"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version 'from' not found"})
+		return
+	}
+	to, ok := findBallotRevision(models.DB, match.ID, c.Query("to"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version 'to' not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":             compareBallotSets(from, to),
+		"from":             from.Revision,
+		"to":               to.Revision,
+		"same_adjudicator": from.AdjudicatorID == to.AdjudicatorID,
+	})
+}
+
+// POST /api/matches/:id/ballot-versions/:revision/rollback
+// Memberlakukan kembali versi lama: versi lain juri yang sama dibuang, lalu hasil match &
+// klasemen dihitung ulang. Versi yang masih menunggu konfirmasi harus lewat /confirm.
+// Body: {"match_version": 3} (versi match yang dilihat; 409 jika sudah berubah)
+func RollbackBallotVersion(c *gin.Context) {
+	var input struct {
+		MatchVersion *int `json:"match_version"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !requireVersion(c, input.MatchVersion) {
+		return
+	}
+
+	tx := models.DB.Begin()
+
+	var match models.Match
+	if err := lockMatch(tx, &match, c.Param("id")); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+	if match.Version != *input.MatchVersion {
+		tx.Rollback()
+		staleVersion(c, match)
+		return
+	}
+	set, ok := findBallotRevision(tx, match.ID, c.Param("revision"))
+	if !ok {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return
+	}
+	switch set.Status {
+	case BallotConfirmed:
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Version is already in effect"})
+		return
+	case BallotSubmitted:
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Version is awaiting confirmation; confirm it instead"})
+		return
+	}
+
+	now := time.Now()
+	set.Status = BallotConfirmed
+	set.ConfirmedAt = &now
+	if userID, ok := currentUserID(c); ok {
+		set.ConfirmedByID = &userID
+	}
+	updated, err := updateVersioned(tx, &set, set.Version, map[string]interface{}{
+		"status": set.Status, "confirmed_by_id": set.ConfirmedByID, "confirmed_at": now,
+	})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": errStaleVersion.Error()})
+		return
+	}
+	set.Version++
+	if err := supersedeBallotSets(tx, set); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var decision panelDecision
+	if !set.IsTrainee {
+		var tournamentID uint
+		if match.Round != nil {
+			tournamentID = match.Round.TournamentID
+		}
+		if decision, err = applyMatchDecision(tx, &match, tournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":             set,
+		"current_revision": match.CurrentBallotRevision,
+		"winner_id":        match.WinnerID,
+		"match_version":    match.Version,
+		"decision":         decision,
+	})
+}
@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// categoryEntry: kategori beserta jumlah speaker dan tim yang eligible
+type categoryEntry struct {
+	models.SpeakerCategory
+	SpeakerCount    int    `json:"speaker_count"`
+	EligibleTeamIDs []uint `json:"eligible_team_ids"`
+}
+
+// categorySpeakers mengembalikan himpunan speaker yang masuk kategori
+func categorySpeakers(db *gorm.DB, categoryID uint) (map[uint]bool, error) {
+	var speakerIDs []uint
+	if err := db.Model(&models.SpeakerCategoryAssignment{}).
+		Where("speaker_category_id = ?", categoryID).
+		Pluck("speaker_id", &speakerIDs).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[uint]bool)
+	for _, id := range speakerIDs {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// categoryEligibleTeams mengembalikan tim yang semua speaker-nya masuk kategori.
+// Tim tanpa speaker tidak pernah eligible.
+func categoryEligibleTeams(db *gorm.DB, category models.SpeakerCategory) (map[uint]bool, error) {
+	members, err := categorySpeakers(db, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	var speakers []models.Speaker
+	if err := db.Joins("JOIN teams ON teams.id = speakers.team_id").
+		Where("teams.tournament_id = ? AND teams.deleted_at IS NULL", category.TournamentID).
+		Find(&speakers).Error; err != nil {
+		return nil, err
+	}
+	eligible := make(map[uint]bool)
+	for _, speaker := range speakers {
+		if _, seen := eligible[speaker.TeamID]; !seen {
+			eligible[speaker.TeamID] = true
+		}
+		if !members[speaker.ID] {
+			eligible[speaker.TeamID] = false
+		}
+	}
+	for teamID, ok := range eligible {
+		if !ok {
+			delete(eligible, teamID)
+		}
+	}
+	return eligible, nil
+}
+
+// loadCategoryFilter membaca ?category_id= untuk standings. Jika gagal, response error sudah
+// dikirim dan hasil kedua bernilai false; tanpa filter hasilnya nil.
+func loadCategoryFilter(c *gin.Context) (*models.SpeakerCategory, bool) {
+	categoryID := c.Query("category_id")
+	if categoryID == "" {
+		return nil, true
+	}
+	if _, err := strconv.Atoi(categoryID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category_id"})
+		return nil, false
+	}
+	var category models.SpeakerCategory
+	if err := models.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker category not found"})
+		return nil, false
+	}
+	if tournamentID := c.Query("tournament_id"); tournamentID != "" && tournamentID != strconv.Itoa(int(category.TournamentID)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Speaker category does not belong to this tournament"})
+		return nil, false
+	}
+	return &category, true
+}
+
+// setSpeakerCategories mengganti seluruh kategori seorang speaker
+func setSpeakerCategories(tx *gorm.DB, speakerID uint, categoryIDs []uint) error {
+	if err := tx.Where("speaker_id = ?", speakerID).Delete(&models.SpeakerCategoryAssignment{}).Error; err != nil {
+		return err
+	}
+	seen := make(map[uint]bool)
+	for _, categoryID := range categoryIDs {
+		if seen[categoryID] {
+			continue
+		}
+		seen[categoryID] = true
+		if err := tx.Create(&models.SpeakerCategoryAssignment{SpeakerID: speakerID, SpeakerCategoryID: categoryID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GET /api/speaker-categories?tournament_id=1
+func GetSpeakerCategories(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	if tournamentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id is required"})
+		return
+	}
+	if _, err := strconv.Atoi(tournamentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+		return
+	}
+
+	var categories []models.SpeakerCategory
+	if err := models.DB.Where("tournament_id = ?", tournamentID).Order("priority desc, name asc").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]categoryEntry, 0, len(categories))
+	for _, category := range categories {
+		members, err := categorySpeakers(models.DB, category.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		eligible, err := categoryEligibleTeams(models.DB, category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		entry := categoryEntry{SpeakerCategory: category, SpeakerCount: len(members), EligibleTeamIDs: []uint{}}
+		for teamID := range eligible {
+			entry.EligibleTeamIDs = append(entry.EligibleTeamIDs, teamID)
+		}
+		result = append(result, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// validateSpeakerCategory mengecek nama kosong dan nama ganda dalam satu turnamen
+func validateSpeakerCategory(db *gorm.DB, category models.SpeakerCategory) string {
+	if category.Name == "" {
+		return "name is required"
+	}
+	var count int64
+	db.Model(&models.SpeakerCategory{}).
+		Where("tournament_id = ? AND LOWER(name) = ? AND id <> ?", category.TournamentID, strings.ToLower(category.Name), category.ID).
+		Count(&count)
+	if count > 0 {
+		return "A speaker category with that name already exists"
+	}
+	return ""
+}
+
+// POST /api/speaker-categories
+// Body: {"tournament_id": 1, "name": "Novice", "priority": 1}
+func CreateSpeakerCategory(c *gin.Context) {
+	var input models.SpeakerCategory
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var tournament models.Tournament
+	if err := models.DB.First(&tournament, input.TournamentID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tournament not found"})
+		return
+	}
+	input.ID = 0
+	input.Name = strings.TrimSpace(input.Name)
+	if msg := validateSpeakerCategory(models.DB, input); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := models.DB.Create(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": input})
+}
+
+// PUT /api/speaker-categories/:id
+func UpdateSpeakerCategory(c *gin.Context) {
+	var category models.SpeakerCategory
+	if err := models.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker category not found"})
+		return
+	}
+	var input struct {
+		Name     *string `json:"name"`
+		Priority *int    `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != nil {
+		category.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Priority != nil {
+		category.Priority = *input.Priority
+	}
+	if msg := validateSpeakerCategory(models.DB, category); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := models.DB.Model(&category).Select("name", "priority").Updates(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+// DELETE /api/speaker-categories/:id
+func DeleteSpeakerCategory(c *gin.Context) {
+	var category models.SpeakerCategory
+	if err := models.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker category not found"})
+		return
+	}
+	tx := models.DB.Begin()
+	if err := tx.Where("speaker_category_id = ?", category.ID).Delete(&models.SpeakerCategoryAssignment{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Delete(&category).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Speaker category deleted successfully"})
+}
+
+// PUT /api/speakers/:id/categories
+// Body: {"category_ids": [1, 2]} - mengganti seluruh kategori speaker ([] untuk mengosongkan)
+func UpdateSpeakerCategories(c *gin.Context) {
+	var input struct {
+		CategoryIDs []uint `json:"category_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var speaker models.Speaker
+	if err := models.DB.Preload("Team").First(&speaker, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker not found"})
+		return
+	}
+	if len(input.CategoryIDs) > 0 {
+		var count int64
+		models.DB.Model(&models.SpeakerCategory{}).
+			Where("id IN ? AND tournament_id = ?", input.CategoryIDs, speaker.Team.TournamentID).
+			Count(&count)
+		unique := make(map[uint]bool)
+		for _, id := range input.CategoryIDs {
+			unique[id] = true
+		}
+		if int(count) != len(unique) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Some categories do not belong to the speaker's tournament"})
+			return
+		}
+	}
+
+	tx := models.DB.Begin()
+	if err := setSpeakerCategories(tx, speaker.ID, input.CategoryIDs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	models.DB.Preload("Categories").First(&speaker, speaker.ID)
+	c.JSON(http.StatusOK, gin.H{"data": speaker})
+}
+
+// POST /api/speaker-categories/import-csv?tournament_id=1
+// Format CSV: team,speaker,categories (beberapa kategori dipisah ";", kosong = hapus kategori).
+// Kategori speaker di CSV menggantikan kategori sebelumnya.
+func ImportSpeakerCategoriesCSV(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	if tournamentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id is required"})
+		return
+	}
+
+	tid, err := strconv.Atoi(tournamentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+		return
+	}
+
+	var input struct {
+		Data [][]string `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var categories []models.SpeakerCategory
+	models.DB.Where("tournament_id = ?", tid).Find(&categories)
+	categoryByName := make(map[string]uint)
+	for _, category := range categories {
+		categoryByName[strings.ToLower(category.Name)] = category.ID
+	}
+	var speakers []models.Speaker
+	models.DB.Preload("Team").
+		Joins("JOIN teams ON teams.id = speakers.team_id").
+		Where("teams.tournament_id = ?", tid).
+		Find(&speakers)
+	speakerByName := make(map[string]uint)
+	for _, speaker := range speakers {
+		key := strings.ToLower(strings.TrimSpace(speaker.Team.Name)) + "|" + strings.ToLower(strings.TrimSpace(speaker.Name))
+		speakerByName[key] = speaker.ID
+	}
+
+	tx := models.DB.Begin()
+	updated := 0
+
+	for rowIdx, row := range input.Data {
+		// Skip header row if detected
+		if rowIdx == 0 && len(row) > 0 && strings.EqualFold(row[0], "team") {
+			continue
+		}
+
+		if len(row) < 2 || row[0] == "" {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(row[0])) + "|" + strings.ToLower(strings.TrimSpace(row[1]))
+		speakerID, ok := speakerByName[key]
+		if !ok {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: speaker '%s' of team '%s' not found", rowIdx+1, row[1], row[0])})
+			return
+		}
+
+		var categoryIDs []uint
+		if len(row) > 2 {
+			for _, name := range strings.Split(row[2], ";") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+				categoryID, ok := categoryByName[strings.ToLower(name)]
+				if !ok {
+					tx.Rollback()
+					c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: category '%s' not found", rowIdx+1, name)})
+					return
+				}
+				categoryIDs = append(categoryIDs, categoryID)
+			}
+		}
+
+		if err := setSpeakerCategories(tx, speakerID, categoryIDs); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Row %d: %s", rowIdx+1, err.Error())})
+			return
+		}
+		updated++
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "CSV imported successfully",
+		"speakers_updated": updated,
+	})
+}
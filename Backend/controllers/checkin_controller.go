@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Check-in speaker dicatat per orang; tim dianggap hadir jika semua speaker-nya hadir
+const EntitySpeaker = "speaker"
+
+// Cara check-in
+const (
+	CheckInManual = "manual"
+	CheckInQR     = "qr"
+)
+
+// Prefix isi QR code: EDS-CHECKIN:<s|a>:<private key>
+const checkInCodePrefix = "EDS-CHECKIN:"
+
+// checkInEntry: status check-in satu entitas di satu ronde
+type checkInEntry struct {
+	EntityID    uint       `json:"entity_id"`
+	Name        string     `json:"name"`
+	TeamID      uint       `json:"team_id,omitempty"`
+	CheckedIn   bool       `json:"checked_in"`
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	Method      string     `json:"method,omitempty"`
+}
+
+func generatePrivateKey() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// checkInCode mengembalikan isi QR code seseorang, membuat private key jika belum ada
+func checkInCode(db *gorm.DB, model interface{}, id uint, key string, kind string) (string, error) {
+	if key == "" {
+		generated, err := generatePrivateKey()
+		if err != nil {
+			return "", err
+		}
+		if err := db.Model(model).Where("id = ?", id).Update("private_key", generated).Error; err != nil {
+			return "", err
+		}
+		key = generated
+	}
+	return checkInCodePrefix + kind + ":" + key, nil
+}
+
+// checkedInSet mengembalikan ID entitas yang sudah check-in di ronde ini
+func checkedInSet(db *gorm.DB, round models.Round, entityType string) (map[uint]bool, error) {
+	recordType := entityType
+	if entityType == EntityTeam {
+		recordType = EntitySpeaker
+	}
+	var ids []uint
+	if err := db.Model(&models.CheckIn{}).Where("round_id = ? AND entity_type = ?", round.ID, recordType).
+		Pluck("entity_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	checked := make(map[uint]bool)
+	for _, id := range ids {
+		checked[id] = true
+	}
+	if entityType != EntityTeam {
+		return checked, nil
+	}
+
+	var speakers []models.Speaker
+	if err := db.Joins("JOIN teams ON teams.id = speakers.team_id").
+		Where("teams.tournament_id = ? AND teams.deleted_at IS NULL", round.TournamentID).
+		Find(&speakers).Error; err != nil {
+		return nil, err
+	}
+	// Tim hadir jika jumlah speaker yang check-in (termasuk substitute) mencukupi speaker inti
+	required := make(map[uint]int)
+	present := make(map[uint]int)
+	for _, speaker := range speakers {
+		if !speaker.IsSubstitute {
+			required[speaker.TeamID]++
+		}
+		if checked[speaker.ID] {
+			present[speaker.TeamID]++
+		}
+	}
+	teams := make(map[uint]bool)
+	for teamID, count := range present {
+		if count >= required[teamID] {
+			teams[teamID] = true
+		}
+	}
+	return teams, nil
+}
+
+func validCheckInType(entityType string) bool {
+	return entityType == EntityTeam || entityType == EntitySpeaker || entityType == EntityAdjudicator || entityType == EntityRoom
+}
+
+// checkInEntities mengambil semua entitas turnamen beserta status check-in-nya
+func checkInEntities(db *gorm.DB, round models.Round, entityType string) ([]checkInEntry, error) {
+	var entries []checkInEntry
+	switch entityType {
+	case EntityTeam:
+		var teams []models.Team
+		if err := db.Where("tournament_id = ? AND is_swing = ?", round.TournamentID, false).Order("name asc").Find(&teams).Error; err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			entries = append(entries, checkInEntry{EntityID: team.ID, Name: team.Name})
+		}
+	case EntitySpeaker:
+		var speakers []models.Speaker
+		if err := db.Joins("JOIN teams ON teams.id = speakers.team_id").
+			Where("teams.tournament_id = ? AND teams.is_swing = ? AND teams.deleted_at IS NULL", round.TournamentID, false).
+			Order("speakers.team_id asc, speakers.id asc").Find(&speakers).Error; err != nil {
+			return nil, err
+		}
+		for _, speaker := range speakers {
+			entries = append(entries, checkInEntry{EntityID: speaker.ID, Name: speaker.Name, TeamID: speaker.TeamID})
+		}
+	case EntityAdjudicator:
+		var adjudicators []models.Adjudicator
+		if err := db.Where("tournament_id = ?", round.TournamentID).Order("name asc").Find(&adjudicators).Error; err != nil {
+			return nil, err
+		}
+		for _, adj := range adjudicators {
+			entries = append(entries, checkInEntry{EntityID: adj.ID, Name: adj.Name})
+		}
+	case EntityRoom:
+		var rooms []models.Room
+		if err := db.Where("tournament_id = ?", round.TournamentID).Order("name asc").Find(&rooms).Error; err != nil {
+			return nil, err
+		}
+		for _, room := range rooms {
+			entries = append(entries, checkInEntry{EntityID: room.ID, Name: room.Name})
+		}
+	}
+
+	checked, err := checkedInSet(db, round, entityType)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[uint]models.CheckIn)
+	if entityType != EntityTeam {
+		var list []models.CheckIn
+		if err := db.Where("round_id = ? AND entity_type = ?", round.ID, entityType).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, record := range list {
+			records[record.EntityID] = record
+		}
+	}
+	for i := range entries {
+		entries[i].CheckedIn = checked[entries[i].EntityID]
+		if record, ok := records[entries[i].EntityID]; ok {
+			at := record.CheckedInAt
+			entries[i].CheckedInAt = &at
+			entries[i].Method = record.Method
+		}
+	}
+	return entries, nil
+}
+
+// setCheckIn mencatat atau menghapus check-in satu entitas
+func setCheckIn(tx *gorm.DB, roundID uint, entityType string, entityID uint, checkedIn bool, method string) error {
+	if !checkedIn {
+		return tx.Unscoped().Where("round_id = ? AND entity_type = ? AND entity_id = ?", roundID, entityType, entityID).
+			Delete(&models.CheckIn{}).Error
+	}
+	record := models.CheckIn{RoundID: roundID, EntityType: entityType, EntityID: entityID}
+	return tx.Where(record).Attrs(models.CheckIn{CheckedInAt: time.Now(), Method: method}).FirstOrCreate(&record).Error
+}
+
+// GET /api/rounds/:id/check-ins?entity_type=team|speaker|adjudicator|room
+func GetCheckIns(c *gin.Context) {
+	entityType := c.Query("entity_type")
+	if !validCheckInType(entityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_type must be 'team', 'speaker', 'adjudicator' or 'room'"})
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	entries, err := checkInEntities(models.DB, round, entityType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	checkedIn := 0
+	for _, entry := range entries {
+		if entry.CheckedIn {
+			checkedIn++
+		}
+	}
+	if entries == nil {
+		entries = []checkInEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries, "checked_in": checkedIn, "total": len(entries)})
+}
+
+// PUT /api/rounds/:id/check-ins
+// Toggle manual: {"entity_type": "speaker", "ids": [1,2], "checked_in": true}.
+// entity_type "team" meng-check-in semua speaker tim tersebut.
+func UpdateCheckIns(c *gin.Context) {
+	var input struct {
+		EntityType string `json:"entity_type"`
+		IDs        []uint `json:"ids"`
+		CheckedIn  bool   `json:"checked_in"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validCheckInType(input.EntityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_type must be 'team', 'speaker', 'adjudicator' or 'room'"})
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	entries, err := checkInEntities(models.DB, round, input.EntityType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	known := make(map[uint]bool)
+	for _, entry := range entries {
+		known[entry.EntityID] = true
+	}
+	for _, id := range input.IDs {
+		if !known[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Some ids do not belong to this tournament", "entity_id": id})
+			return
+		}
+	}
+
+	recordType, ids := input.EntityType, input.IDs
+	if input.EntityType == EntityTeam {
+		recordType, ids = EntitySpeaker, nil
+		if len(input.IDs) > 0 {
+			models.DB.Model(&models.Speaker{}).Where("team_id IN ?", input.IDs).Pluck("id", &ids)
+		}
+	}
+
+	tx := models.DB.Begin()
+	for _, id := range ids {
+		if err := setCheckIn(tx, round.ID, recordType, id, input.CheckedIn, CheckInManual); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	entries, _ = checkInEntities(models.DB, round, input.EntityType)
+	c.JSON(http.StatusOK, gin.H{"data": entries, "updated": len(ids)})
+}
+
+// POST /api/rounds/:id/check-ins/scan
+// Body: {"code": "EDS-CHECKIN:s:<private key>"} hasil scan QR code peserta
+func ScanCheckIn(c *gin.Context) {
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	code := strings.TrimSpace(input.Code)
+	parts := strings.SplitN(strings.TrimPrefix(code, checkInCodePrefix), ":", 2)
+	if !strings.HasPrefix(code, checkInCodePrefix) || len(parts) != 2 || parts[1] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in code"})
+		return
+	}
+
+	var entityType, name string
+	var entityID, teamID uint
+	switch parts[0] {
+	case "s":
+		var speaker models.Speaker
+		if err := models.DB.Joins("JOIN teams ON teams.id = speakers.team_id").
+			Where("speakers.private_key = ? AND teams.tournament_id = ?", parts[1], round.TournamentID).
+			First(&speaker).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found in this tournament"})
+			return
+		}
+		entityType, entityID, name, teamID = EntitySpeaker, speaker.ID, speaker.Name, speaker.TeamID
+	case "a":
+		var adj models.Adjudicator
+		if err := models.DB.Where("private_key = ? AND tournament_id = ?", parts[1], round.TournamentID).First(&adj).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found in this tournament"})
+			return
+		}
+		entityType, entityID, name = EntityAdjudicator, adj.ID, adj.Name
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in code"})
+		return
+	}
+
+	if err := setCheckIn(models.DB, round.ID, entityType, entityID, true, CheckInQR); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Checked in",
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"team_id":     teamID,
+		"name":        name,
+	})
+}
+
+// GET /api/speakers/:id/check-in-code
+// Isi QR code check-in speaker (di-render menjadi QR oleh frontend)
+func GetSpeakerCheckInCode(c *gin.Context) {
+	var speaker models.Speaker
+	if err := models.DB.First(&speaker, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker not found"})
+		return
+	}
+	code, err := checkInCode(models.DB, &models.Speaker{}, speaker.ID, speaker.PrivateKey, "s")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"speaker_id": speaker.ID, "name": speaker.Name, "code": code}})
+}
+
+// GET /api/adjudicators/:id/check-in-code
+func GetAdjudicatorCheckInCode(c *gin.Context) {
+	var adj models.Adjudicator
+	if err := models.DB.First(&adj, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator not found"})
+		return
+	}
+	code, err := checkInCode(models.DB, &models.Adjudicator{}, adj.ID, adj.PrivateKey, "a")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"adjudicator_id": adj.ID, "name": adj.Name, "code": code}})
+}
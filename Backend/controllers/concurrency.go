@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errStaleVersion: update bersyarat versi tidak mengenai baris mana pun
+var errStaleVersion = errors.New("data sudah diubah user lain")
+
+// versionErrorStatus: 409 untuk errStaleVersion, selain itu 500
+func versionErrorStatus(err error) int {
+	if errors.Is(err, errStaleVersion) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// requireVersion: endpoint edit wajib menyertakan versi data yang diedit klien
+func requireVersion(c *gin.Context, version *int) bool {
+	if version == nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "version is required (kirim versi data yang diedit)"})
+		return false
+	}
+	return true
+}
+
+// updateVersioned menyimpan kolom hanya jika versi di database masih sama dengan versi yang
+// diedit klien, sekaligus menaikkan versi. false = data sudah diubah user lain (409).
+func updateVersioned(tx *gorm.DB, model interface{}, version int, values map[string]interface{}) (bool, error) {
+	values["version"] = gorm.Expr("version + 1")
+	result := tx.Model(model).Where("version = ?", version).Updates(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+// staleVersion: respons 409 beserta kondisi data terkini
+func staleVersion(c *gin.Context, current interface{}) {
+	c.JSON(http.StatusConflict, gin.H{"error": "Data sudah diubah user lain, muat ulang lalu ulangi", "current": current})
+}
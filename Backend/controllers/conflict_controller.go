@@ -0,0 +1,423 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Jenis konflik juri
+const (
+	ConflictTeam        = "team"
+	ConflictInstitution = "institution"
+	ConflictAdjudicator = "adjudicator"
+)
+
+// panelConflict: satu konflik yang ditemukan pada sebuah panel
+type panelConflict struct {
+	AdjudicatorID      uint   `json:"adjudicator_id"`
+	TeamID             uint   `json:"team_id,omitempty"`
+	OtherAdjudicatorID uint   `json:"other_adjudicator_id,omitempty"`
+	Reason             string `json:"reason"`
+}
+
+// conflictIndex: semua konflik satu turnamen, termasuk konflik institusi sendiri yang otomatis
+type conflictIndex struct {
+	teamInstitution map[uint]string
+	adjInstitution  map[uint]string
+	teams           map[uint]map[uint]string // adjudicator -> team -> alasan
+	institutions    map[uint]map[string]string
+	adjudicators    map[pairKey]string
+}
+
+func normalizeInstitution(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func conflictReason(conflict models.AdjudicatorConflict, fallback string) string {
+	if conflict.Reason != "" {
+		return conflict.Reason
+	}
+	return fallback
+}
+
+// loadConflicts membangun conflictIndex untuk sebuah turnamen
+func loadConflicts(db *gorm.DB, tournamentID uint) (*conflictIndex, error) {
+	index := &conflictIndex{
+		teamInstitution: make(map[uint]string),
+		adjInstitution:  make(map[uint]string),
+		teams:           make(map[uint]map[uint]string),
+		institutions:    make(map[uint]map[string]string),
+		adjudicators:    make(map[pairKey]string),
+	}
+
+	var teams []models.Team
+	if err := db.Where("tournament_id = ?", tournamentID).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		index.teamInstitution[team.ID] = normalizeInstitution(team.Institution)
+	}
+
+	var adjudicators []models.Adjudicator
+	if err := db.Where("tournament_id = ?", tournamentID).Find(&adjudicators).Error; err != nil {
+		return nil, err
+	}
+	for _, adj := range adjudicators {
+		index.adjInstitution[adj.ID] = normalizeInstitution(adj.Institution)
+	}
+
+	var conflicts []models.AdjudicatorConflict
+	if err := db.Where("tournament_id = ?", tournamentID).Find(&conflicts).Error; err != nil {
+		return nil, err
+	}
+	for _, conflict := range conflicts {
+		switch conflict.ConflictType {
+		case ConflictTeam:
+			if conflict.TeamID == nil {
+				continue
+			}
+			if index.teams[conflict.AdjudicatorID] == nil {
+				index.teams[conflict.AdjudicatorID] = make(map[uint]string)
+			}
+			index.teams[conflict.AdjudicatorID][*conflict.TeamID] = conflictReason(conflict, "Team conflict")
+		case ConflictInstitution:
+			if index.institutions[conflict.AdjudicatorID] == nil {
+				index.institutions[conflict.AdjudicatorID] = make(map[string]string)
+			}
+			index.institutions[conflict.AdjudicatorID][normalizeInstitution(conflict.Institution)] = conflictReason(conflict, "Institution conflict: "+conflict.Institution)
+		case ConflictAdjudicator:
+			if conflict.OtherAdjudicatorID == nil {
+				continue
+			}
+			index.adjudicators[newPairKey(conflict.AdjudicatorID, *conflict.OtherAdjudicatorID)] = conflictReason(conflict, "Adjudicator conflict")
+		}
+	}
+	return index, nil
+}
+
+// teamConflict mengecek apakah juri tidak boleh menilai tim ini
+func (index *conflictIndex) teamConflict(adjID, teamID uint) (string, bool) {
+	if reason, ok := index.teams[adjID][teamID]; ok {
+		return reason, true
+	}
+	institution := index.teamInstitution[teamID]
+	if institution == "" {
+		return "", false
+	}
+	if index.adjInstitution[adjID] == institution {
+		return "Own institution", true
+	}
+	if reason, ok := index.institutions[adjID][institution]; ok {
+		return reason, true
+	}
+	return "", false
+}
+
+// panelConflicts mencari semua konflik juri-tim dan juri-juri di sebuah panel
+func (index *conflictIndex) panelConflicts(teamIDs []uint, adjIDs []uint) []panelConflict {
+	var conflicts []panelConflict
+	for i, adjID := range adjIDs {
+		for _, teamID := range teamIDs {
+			if reason, ok := index.teamConflict(adjID, teamID); ok {
+				conflicts = append(conflicts, panelConflict{AdjudicatorID: adjID, TeamID: teamID, Reason: reason})
+			}
+		}
+		for _, otherID := range adjIDs[i+1:] {
+			if reason, ok := index.adjudicators[newPairKey(adjID, otherID)]; ok {
+				conflicts = append(conflicts, panelConflict{AdjudicatorID: adjID, OtherAdjudicatorID: otherID, Reason: reason})
+			}
+		}
+	}
+	return conflicts
+}
+
+// matchTeamIDs mengembalikan semua tim yang bertanding di sebuah match (AP maupun BP)
+func matchTeamIDs(match models.Match) []uint {
+	var ids []uint
+	for _, id := range []*uint{match.GovTeamID, match.OppTeamID, match.OGTeamID, match.OOTeamID, match.CGTeamID, match.COTeamID} {
+		if id != nil && *id != 0 {
+			ids = append(ids, *id)
+		}
+	}
+	return ids
+}
+
+// panelConflictIssues menandai panel yang dialokasikan dengan konflik (warning, karena bisa di-override)
+func panelConflictIssues(db *gorm.DB, round models.Round, matches []models.Match) ([]drawIssue, error) {
+	index, err := loadConflicts(db, round.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+	panels, err := loadRoundPanels(db, round.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjudicators []models.Adjudicator
+	if err := db.Where("tournament_id = ?", round.TournamentID).Find(&adjudicators).Error; err != nil {
+		return nil, err
+	}
+	adjName := make(map[uint]string)
+	for _, adj := range adjudicators {
+		adjName[adj.ID] = adj.Name
+	}
+
+	issues := []drawIssue{}
+	for _, match := range matches {
+		var adjIDs []uint
+		for _, member := range panels[match.ID] {
+			adjIDs = append(adjIDs, member.AdjudicatorID)
+		}
+		for _, conflict := range index.panelConflicts(matchTeamIDs(match), adjIDs) {
+			issue := drawIssue{Severity: "warning", MatchID: match.ID, TeamID: conflict.TeamID}
+			if conflict.OtherAdjudicatorID != 0 {
+				issue.Message = fmt.Sprintf("Adjudicators %s and %s are conflicted (%s)", adjName[conflict.AdjudicatorID], adjName[conflict.OtherAdjudicatorID], conflict.Reason)
+			} else {
+				issue.Message = fmt.Sprintf("Adjudicator %s is conflicted with a team (%s)", adjName[conflict.AdjudicatorID], conflict.Reason)
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// validateConflict memastikan record konflik lengkap dan semua entitasnya milik turnamen yang sama
+func validateConflict(db *gorm.DB, conflict *models.AdjudicatorConflict) error {
+	var adj models.Adjudicator
+	if err := db.Where("id = ? AND tournament_id = ?", conflict.AdjudicatorID, conflict.TournamentID).First(&adj).Error; err != nil {
+		return errors.New("Adjudicator not found in this tournament")
+	}
+
+	switch conflict.ConflictType {
+	case ConflictTeam:
+		if conflict.TeamID == nil {
+			return errors.New("team_id is required for a team conflict")
+		}
+		var team models.Team
+		if err := db.Where("id = ? AND tournament_id = ?", *conflict.TeamID, conflict.TournamentID).First(&team).Error; err != nil {
+			return errors.New("Team not found in this tournament")
+		}
+		conflict.Institution, conflict.OtherAdjudicatorID = "", nil
+	case ConflictInstitution:
+		conflict.Institution = strings.TrimSpace(conflict.Institution)
+		if conflict.Institution == "" {
+			return errors.New("institution is required for an institution conflict")
+		}
+		conflict.TeamID, conflict.OtherAdjudicatorID = nil, nil
+	case ConflictAdjudicator:
+		if conflict.OtherAdjudicatorID == nil {
+			return errors.New("other_adjudicator_id is required for an adjudicator conflict")
+		}
+		if *conflict.OtherAdjudicatorID == conflict.AdjudicatorID {
+			return errors.New("An adjudicator cannot conflict with themselves")
+		}
+		var other models.Adjudicator
+		if err := db.Where("id = ? AND tournament_id = ?", *conflict.OtherAdjudicatorID, conflict.TournamentID).First(&other).Error; err != nil {
+			return errors.New("Other adjudicator not found in this tournament")
+		}
+		conflict.TeamID, conflict.Institution = nil, ""
+	default:
+		return errors.New("conflict_type must be 'team', 'institution' or 'adjudicator'")
+	}
+	return nil
+}
+
+// GET /api/adjudicator-conflicts?tournament_id=1&adjudicator_id=2
+func GetAdjudicatorConflicts(c *gin.Context) {
+	query := models.DB.Order("id asc")
+	for _, param := range []string{"tournament_id", "adjudicator_id"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+		query = query.Where(param+" = ?", value)
+	}
+
+	var conflicts []models.AdjudicatorConflict
+	if err := query.Find(&conflicts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if conflicts == nil {
+		conflicts = []models.AdjudicatorConflict{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": conflicts})
+}
+
+// POST /api/adjudicator-conflicts
+func CreateAdjudicatorConflict(c *gin.Context) {
+	var input models.AdjudicatorConflict
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateConflict(models.DB, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := models.DB.Create(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": input})
+}
+
+// PUT /api/adjudicator-conflicts/:id
+func UpdateAdjudicatorConflict(c *gin.Context) {
+	var conflict models.AdjudicatorConflict
+	if err := models.DB.First(&conflict, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator conflict not found"})
+		return
+	}
+
+	var input models.AdjudicatorConflict
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.ID = conflict.ID
+	input.CreatedAt = conflict.CreatedAt
+	input.TournamentID = conflict.TournamentID
+	if err := validateConflict(models.DB, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := models.DB.Save(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": input})
+}
+
+// DELETE /api/adjudicator-conflicts/:id
+func DeleteAdjudicatorConflict(c *gin.Context) {
+	var conflict models.AdjudicatorConflict
+	if err := models.DB.First(&conflict, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator conflict not found"})
+		return
+	}
+	if err := models.DB.Delete(&conflict).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Adjudicator conflict deleted successfully"})
+}
+
+// CSV Import Adjudicator Conflicts
+// Format CSV: adjudicator,type,target,reason
+// type: team (target = nama tim), institution (target = nama institusi), adjudicator (target = nama juri)
+func ImportAdjudicatorConflictsCSV(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	if tournamentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id is required"})
+		return
+	}
+
+	tid, err := strconv.Atoi(tournamentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+		return
+	}
+
+	var input struct {
+		Data [][]string `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var adjudicators []models.Adjudicator
+	models.DB.Where("tournament_id = ?", tid).Find(&adjudicators)
+	adjByName := make(map[string]uint)
+	for _, adj := range adjudicators {
+		adjByName[strings.ToLower(strings.TrimSpace(adj.Name))] = adj.ID
+	}
+	var teams []models.Team
+	models.DB.Where("tournament_id = ?", tid).Find(&teams)
+	teamByName := make(map[string]uint)
+	for _, team := range teams {
+		teamByName[strings.ToLower(strings.TrimSpace(team.Name))] = team.ID
+	}
+
+	tx := models.DB.Begin()
+	created := 0
+
+	for rowIdx, row := range input.Data {
+		// Skip header row if detected
+		if rowIdx == 0 && len(row) > 0 && strings.EqualFold(row[0], "adjudicator") {
+			continue
+		}
+
+		if len(row) < 3 || row[0] == "" {
+			continue
+		}
+
+		adjID, ok := adjByName[strings.ToLower(strings.TrimSpace(row[0]))]
+		if !ok {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: adjudicator '%s' not found", rowIdx+1, row[0])})
+			return
+		}
+
+		conflict := models.AdjudicatorConflict{
+			TournamentID:  uint(tid),
+			AdjudicatorID: adjID,
+			ConflictType:  strings.ToLower(strings.TrimSpace(row[1])),
+		}
+		if len(row) > 3 {
+			conflict.Reason = strings.TrimSpace(row[3])
+		}
+
+		target := strings.TrimSpace(row[2])
+		switch conflict.ConflictType {
+		case ConflictTeam:
+			teamID, ok := teamByName[strings.ToLower(target)]
+			if !ok {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: team '%s' not found", rowIdx+1, target)})
+				return
+			}
+			conflict.TeamID = &teamID
+		case ConflictInstitution:
+			conflict.Institution = target
+		case ConflictAdjudicator:
+			otherID, ok := adjByName[strings.ToLower(target)]
+			if !ok {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: adjudicator '%s' not found", rowIdx+1, target)})
+				return
+			}
+			conflict.OtherAdjudicatorID = &otherID
+		}
+
+		if err := validateConflict(tx, &conflict); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Row %d: %s", rowIdx+1, err.Error())})
+			return
+		}
+		if err := tx.Create(&conflict).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create conflict on row %d: %s", rowIdx+1, err.Error())})
+			return
+		}
+		created++
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "CSV imported successfully",
+		"conflicts_created": created,
+	})
+}
@@ -3,12 +3,17 @@ package controllers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/star_fj/eds-backend/models"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -35,6 +40,20 @@ func setupControllerTestDB() {
 		&models.Match{},
 		&models.Ballot{},
 		&models.Adjudicator{},
+		&models.TournamentSettings{},
+		&models.DrawEdit{},
+		&models.Room{},
+		&models.RoomConstraint{},
+		&models.RoundAvailability{},
+		&models.MatchAdjudicator{},
+		&models.AdjudicatorConflict{},
+		&models.AdjudicatorFeedback{},
+		&models.AdjudicatorScoreHistory{},
+		&models.CheckIn{},
+		&models.SpeakerCategory{},
+		&models.SpeakerCategoryAssignment{},
+		&models.BallotSet{},
+		&models.BallotSubmission{},
 	)
 }
 
@@ -49,34 +68,184 @@ func setupControllerTestRouter() *gin.Engine {
 		api.POST("/tournaments", CreateTournament)
 		api.PUT("/tournaments/:id", UpdateTournament)
 		api.DELETE("/tournaments/:id", DeleteTournament)
+		api.GET("/tournaments/:id/settings", GetTournamentSettings)
+		api.PUT("/tournaments/:id/settings", UpdateTournamentSettings)
 
 		// Team routes
 		api.GET("/teams", GetTeams)
 		api.POST("/teams", CreateTeam)
+		api.PUT("/teams/:id", UpdateTeam)
 		api.DELETE("/teams/:id", DeleteTeam)
+		api.POST("/teams/:id/speakers", AddTeamSpeaker)
+		api.POST("/teams/:id/withdraw", WithdrawTeam)
+		api.PUT("/speakers/:id", UpdateSpeaker)
+		api.DELETE("/speakers/:id", DeleteSpeaker)
+		api.PUT("/speakers/:id/categories", UpdateSpeakerCategories)
+		api.GET("/speaker-categories", GetSpeakerCategories)
+		api.POST("/speaker-categories", CreateSpeakerCategory)
+		api.PUT("/speaker-categories/:id", UpdateSpeakerCategory)
+		api.POST("/speaker-categories/import-csv", ImportSpeakerCategoriesCSV)
 
 		// Round routes
 		api.GET("/rounds", GetRounds)
 		api.POST("/rounds", CreateRound)
 		api.DELETE("/rounds/:id", DeleteRound)
+		api.POST("/rounds/:id/generate-draw", GenerateDraw)
+		api.PUT("/rounds/:id/draw-status", UpdateDrawStatus)
+		api.PUT("/rounds/:id/status", UpdateRoundStatus)
+		api.PUT("/rounds/:id/publish-draw", PublishDraw)
+		api.PUT("/rounds/:id/publish-motion", PublishMotion)
+		api.PUT("/matches/:id/importance", UpdateMatchImportance)
+		api.PUT("/matches/:id/result", UpdateMatchResult)
+		api.POST("/rounds/:id/draw/preview", PreviewDraw)
+		api.POST("/rounds/:id/draw/swap-teams", SwapDrawTeams)
+		api.POST("/rounds/:id/draw/flip-sides", FlipDrawSides)
+		api.POST("/rounds/:id/draw/undo", UndoDrawEdit)
+		api.GET("/rounds/:id/draw/validate", ValidateDraw)
+		api.POST("/rounds/:id/allocate-rooms", AllocateRooms)
+		api.POST("/rounds/:id/allocate-adjudicators", AllocateAdjudicators)
+		api.GET("/rounds/:id/allocation-diagnostics", GetAllocationDiagnostics)
+		api.GET("/rounds/:id/availability", GetRoundAvailability)
+		api.PUT("/rounds/:id/availability", UpdateRoundAvailability)
+		api.GET("/rounds/:id/check-ins", GetCheckIns)
+		api.GET("/rounds/:id/ballot-progress", GetRoundBallotProgress)
+		api.GET("/rounds/:id/print-sheets", GetRoundPrintSheets)
+		api.PUT("/rounds/:id/check-ins", UpdateCheckIns)
+		api.POST("/rounds/:id/check-ins/scan", ScanCheckIn)
+		api.GET("/speakers/:id/check-in-code", GetSpeakerCheckInCode)
 
 		// Match routes
 		api.GET("/matches", GetMatches)
 		api.POST("/matches", CreateMatch)
+		api.PUT("/matches/:id/panel", AssignAdjudicatorPanel)
+		api.GET("/matches/:id/ballots", GetMatchBallotSets)
+		api.GET("/matches/:id/ballot-versions", GetBallotVersions)
+		api.GET("/matches/:id/ballot-versions/diff", DiffBallotVersions)
+		api.POST("/matches/:id/ballot-versions/:revision/rollback", RollbackBallotVersion)
+
+		// Adjudicator routes
+		api.POST("/adjudicators", CreateAdjudicator)
+		api.PUT("/adjudicators/:id", UpdateAdjudicator)
+		api.DELETE("/adjudicators/:id", DeleteAdjudicator)
+		api.PUT("/rooms/:id", UpdateRoom)
+		api.GET("/adjudicators/ranking", GetAdjudicatorRanking)
+		api.PUT("/adjudicators/:id/score", UpdateAdjudicatorScore)
+		api.GET("/adjudicators/:id/score-history", GetAdjudicatorScoreHistory)
+		api.POST("/adjudicator-feedback", func(c *gin.Context) { CreateAdjudicatorFeedback(c, models.DB) })
+
+		// Adjudicator conflict routes
+		api.GET("/adjudicator-conflicts", GetAdjudicatorConflicts)
+		api.POST("/adjudicator-conflicts", CreateAdjudicatorConflict)
+		api.POST("/adjudicator-conflicts/import-csv", ImportAdjudicatorConflictsCSV)
 
 		// Ballot routes
 		api.POST("/submit-ballot", SubmitBallot)
+		api.POST("/ballots/batch", SubmitBallotBatch)
 		api.GET("/ballots", GetBallots)
+		api.POST("/ballot-sets/:id/confirm", ConfirmBallotSet)
+		api.POST("/ballot-sets/:id/discard", DiscardBallotSet)
+		api.GET("/ballot-sets/:id/discrepancies", GetBallotSetDiscrepancies)
+		api.GET("/trainee-report", GetTraineeReport)
 
 		// Standings routes
 		api.GET("/standings/teams", GetStandings)
 		api.GET("/standings/speakers", GetSpeakerStandings)
+		api.GET("/standings/replies", GetReplyStandings)
 		api.POST("/standings/recalculate", RecalculateStandings)
 	}
 
 	return router
 }
 
+// sendJSON mengirim request JSON ke router test; auth (opsional, mis. bearerToken) dipasang sebagai header Authorization
+func sendJSON(router http.Handler, method, url, body string, auth ...string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if len(auth) > 0 && auth[0] != "" {
+		req.Header.Set("Authorization", auth[0])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// bearerToken: header Authorization untuk user yang login
+func bearerToken(userID uint) string {
+	signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString(secretKey)
+	return "Bearer " + signed
+}
+
+// matchVersion / roundVersion: versi terkini, dikirim seperti klien yang baru memuat datanya
+func matchVersion(id uint) int {
+	var match models.Match
+	models.DB.First(&match, id)
+	return match.Version
+}
+
+func roundVersion(id uint) int {
+	var round models.Round
+	models.DB.First(&round, id)
+	return round.Version
+}
+
+// ballotScore: satu isian skor ballot test; speaker dikirim lewat ID, nama, atau keduanya
+type ballotScore struct {
+	SpeakerID uint
+	Name      string
+	Score     float64
+	Position  string
+	TeamRole  string
+	IsReply   bool
+}
+
+// ballotScores menyusun array "scores" ballot (dipakai juga di body konfirmasi)
+func ballotScores(scores ...ballotScore) string {
+	entries := make([]string, 0, len(scores))
+	for _, score := range scores {
+		fields := []string{}
+		if score.SpeakerID != 0 {
+			fields = append(fields, fmt.Sprintf(`"speaker_id":%d`, score.SpeakerID))
+		}
+		if score.Name != "" {
+			fields = append(fields, fmt.Sprintf(`"speaker":{"name":%q}`, score.Name))
+		}
+		fields = append(fields, fmt.Sprintf(`"score":%g,"position":%q,"team_role":%q`, score.Score, score.Position, score.TeamRole))
+		if score.IsReply {
+			fields = append(fields, `"is_reply":true`)
+		}
+		entries = append(entries, "{"+strings.Join(fields, ",")+"}")
+	}
+	return "[" + strings.Join(entries, ",") + "]"
+}
+
+// apScores: isian skor Asian Parliamentary (PM, DPM, GW, LO, DLO, OW) sesuai urutan speakers
+func apScores(speakers []models.Speaker, scores ...float64) []ballotScore {
+	positions := []string{"PM", "DPM", "GW", "LO", "DLO", "OW"}
+	entries := make([]ballotScore, 0, len(scores))
+	for i, score := range scores {
+		role := "gov"
+		if i >= 3 {
+			role = "opp"
+		}
+		entries = append(entries, ballotScore{SpeakerID: speakers[i].ID, Score: score, Position: positions[i], TeamRole: role})
+	}
+	return entries
+}
+
+// ballotBody: body POST /api/submit-ballot. version < 0 = tanpa match_version, winner kosong tidak
+// dikirim; extra berisi field JSON tambahan apa adanya (mis. `"draft":true`)
+func ballotBody(matchID, adjID uint, version int, winner string, scores []ballotScore, extra ...string) string {
+	fields := []string{fmt.Sprintf(`"match_id":%d`, matchID), fmt.Sprintf(`"adjudicator_id":%d`, adjID)}
+	if version >= 0 {
+		fields = append(fields, fmt.Sprintf(`"match_version":%d`, version))
+	}
+	if winner != "" {
+		fields = append(fields, fmt.Sprintf(`"winner":%q`, winner))
+	}
+	fields = append(fields, `"scores":`+ballotScores(scores...))
+	return "{" + strings.Join(append(fields, extra...), ",") + "}"
+}
+
 func TestTournamentController(t *testing.T) {
 	setupControllerTestDB()
 	router := setupControllerTestRouter()
@@ -201,36 +370,75 @@ func TestBallotController(t *testing.T) {
 	adjudicator := models.Adjudicator{Name: "Test Judge", TournamentID: tournament.ID}
 	models.DB.Create(&adjudicator)
 
+	// Speaker dicocokkan dengan nama di tim masing-masing, tidak dibuat otomatis
+	for _, name := range []string{"Gov PM", "Gov DPM", "Gov GW"} {
+		models.DB.Create(&models.Speaker{Name: name, TeamID: govTeam.ID})
+	}
+	for _, name := range []string{"Opp LO", "Opp DLO", "Opp OW"} {
+		models.DB.Create(&models.Speaker{Name: name, TeamID: oppTeam.ID})
+	}
+
+	t.Run("Reject Unknown Speaker", func(t *testing.T) {
+		body := ballotBody(match.ID, adjudicator.ID, matchVersion(match.ID), "gov", []ballotScore{
+			{Name: "Gov PM", Score: 78, Position: "PM", TeamRole: "gov"},
+			{Name: "Gov DPM", Score: 76, Position: "DPM", TeamRole: "gov"},
+			{Name: "Stranger", Score: 75, Position: "GW", TeamRole: "gov"},
+			{Name: "Opp LO", Score: 74, Position: "LO", TeamRole: "opp"},
+			{Name: "Opp DLO", Score: 76, Position: "DLO", TeamRole: "opp"},
+			{Name: "Gov GW", Score: 75, Position: "OW", TeamRole: "opp"},
+		})
+		w := sendJSON(router, "POST", "/api/submit-ballot", body)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Stranger")
+		var count int64
+		models.DB.Model(&models.Speaker{}).Count(&count)
+		assert.Equal(t, int64(6), count)
+	})
+
 	t.Run("Submit Ballot", func(t *testing.T) {
 		ballotData := map[string]interface{}{
 			"match_id":       match.ID,
+			"match_version":  matchVersion(match.ID),
 			"adjudicator_id": adjudicator.ID,
 			"winner":         "gov",
 			"scores": []map[string]interface{}{
 				{
 					"speaker":   map[string]string{"name": "Gov PM"},
-					"score":     85,
+					"score":     78,
 					"position":  "PM",
 					"team_role": "gov",
 				},
 				{
 					"speaker":   map[string]string{"name": "Gov DPM"},
-					"score":     80,
+					"score":     76,
 					"position":  "DPM",
 					"team_role": "gov",
 				},
+				{
+					"speaker":   map[string]string{"name": "Gov GW"},
+					"score":     75,
+					"position":  "GW",
+					"team_role": "gov",
+				},
 				{
 					"speaker":   map[string]string{"name": "Opp LO"},
-					"score":     78,
+					"score":     74,
 					"position":  "LO",
 					"team_role": "opp",
 				},
 				{
 					"speaker":   map[string]string{"name": "Opp DLO"},
-					"score":     82,
+					"score":     76,
 					"position":  "DLO",
 					"team_role": "opp",
 				},
+				{
+					"speaker":   map[string]string{"name": "Opp OW"},
+					"score":     75,
+					"position":  "OW",
+					"team_role": "opp",
+				},
 			},
 		}
 
@@ -248,8 +456,8 @@ func TestBallotController(t *testing.T) {
 
 		assert.Contains(t, response["message"], "Skor disimpan")
 		assert.Equal(t, float64(govTeam.ID), response["winner_id"])
-		assert.Equal(t, float64(165), response["total_gov"]) // 85 + 80
-		assert.Equal(t, float64(160), response["total_opp"]) // 78 + 82
+		assert.Equal(t, float64(229), response["total_gov"]) // 78 + 76 + 75
+		assert.Equal(t, float64(225), response["total_opp"]) // 74 + 76 + 75
 	})
 
 	t.Run("Get Ballots by Match", func(t *testing.T) {
@@ -263,8 +471,2001 @@ func TestBallotController(t *testing.T) {
 		json.Unmarshal(w.Body.Bytes(), &response)
 		data := response["data"].([]interface{})
 
-		assert.Equal(t, 4, len(data)) // 4 speakers
+		assert.Equal(t, 6, len(data)) // 6 speakers
+	})
+}
+
+func TestDrawGenerationWithOddTeams(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Odd Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+		models.DB.Create(&models.Team{Name: name, TournamentID: tournament.ID})
+	}
+
+	generate := func(roundName string) []models.Match {
+		round := models.Round{Name: roundName, TournamentID: tournament.ID}
+		models.DB.Create(&round)
+
+		w := sendJSON(router, "POST", "/api/rounds/"+strconv.Itoa(int(round.ID))+"/generate-draw", `{"version":1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var matches []models.Match
+		models.DB.Where("round_id = ?", round.ID).Find(&matches)
+		return matches
+	}
+
+	t.Run("Bye Win", func(t *testing.T) {
+		matches := generate("Round 1")
+		assert.Equal(t, 3, len(matches))
+
+		var bye models.Match
+		models.DB.Where("is_bye = ?", true).First(&bye)
+		assert.NotNil(t, bye.GovTeamID)
+		assert.Nil(t, bye.OppTeamID)
+		assert.True(t, bye.IsCompleted)
+
+		// Bye di draft draw belum masuk klasemen; dihitung saat draw dirilis
+		var byeTeam models.Team
+		models.DB.First(&byeTeam, *bye.GovTeamID)
+		assert.Equal(t, 0, byeTeam.TotalVP)
+
+		statusPath := "/api/rounds/" + strconv.Itoa(int(bye.RoundID)) + "/draw-status"
+		setStatus := func(status string) {
+			body := fmt.Sprintf(`{"draw_status":"%s","version":%d}`, status, roundVersion(bye.RoundID))
+			assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", statusPath, body).Code)
+		}
+		setStatus(DrawStatusConfirmed)
+		setStatus(DrawStatusReleased)
+		models.DB.First(&byeTeam, byeTeam.ID)
+		assert.Equal(t, 1, byeTeam.TotalVP)
+
+		setStatus(DrawStatusConfirmed)
+		models.DB.First(&byeTeam, byeTeam.ID)
+		assert.Equal(t, 0, byeTeam.TotalVP)
+		setStatus(DrawStatusReleased)
+		models.DB.First(&byeTeam, byeTeam.ID)
+		assert.Equal(t, 1, byeTeam.TotalVP)
+	})
+
+	t.Run("Swing Team", func(t *testing.T) {
+		w := sendJSON(router, "PUT", "/api/tournaments/"+strconv.Itoa(int(tournament.ID))+"/settings", `{"bye_strategy":"swing_team"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		matches := generate("Round 2")
+		assert.Equal(t, 3, len(matches))
+
+		var swing models.Team
+		assert.NoError(t, models.DB.Where("is_swing = ?", true).First(&swing).Error)
+
+		w = sendJSON(router, "GET", "/api/standings/teams?tournament_id="+strconv.Itoa(int(tournament.ID)), "")
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].([]interface{})
+		assert.Equal(t, 5, len(data)) // Swing team tidak masuk klasemen
+	})
+}
+
+func TestDraftDrawEditing(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Edit Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta"} {
+		models.DB.Create(&models.Team{Name: name, TournamentID: tournament.ID})
+	}
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	roundPath := "/api/rounds/" + strconv.Itoa(int(round.ID))
+
+	assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "POST", roundPath+"/generate-draw", "").Code)
+	assert.Equal(t, http.StatusOK, sendJSON(router, "POST", roundPath+"/generate-draw", `{"version":1}`).Code)
+	var before []models.Match
+	models.DB.Where("round_id = ?", round.ID).Order("id asc").Find(&before)
+	assert.Equal(t, 2, len(before))
+
+	t.Run("Swap Teams And Undo", func(t *testing.T) {
+		body := fmt.Sprintf(`{"team_a_id":%d,"team_b_id":%d,"version":%d}`, *before[0].GovTeamID, *before[1].GovTeamID, roundVersion(round.ID))
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", roundPath+"/draw/swap-teams", body).Code)
+		// Edit kedua dengan versi yang sama sudah basi
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", roundPath+"/draw/swap-teams", body).Code)
+
+		var swapped models.Match
+		models.DB.First(&swapped, before[0].ID)
+		assert.Equal(t, *before[1].GovTeamID, *swapped.GovTeamID)
+
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", roundPath+"/draw/undo", fmt.Sprintf(`{"version":%d}`, roundVersion(round.ID))).Code)
+		var restored models.Match
+		models.DB.First(&restored, before[0].ID)
+		assert.Equal(t, *before[0].GovTeamID, *restored.GovTeamID)
+	})
+
+	t.Run("Flip Sides", func(t *testing.T) {
+		body := fmt.Sprintf(`{"match_id":%d,"version":%d}`, before[0].ID, roundVersion(round.ID))
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", roundPath+"/draw/flip-sides", body).Code)
+
+		var flipped models.Match
+		models.DB.First(&flipped, before[0].ID)
+		assert.Equal(t, *before[0].OppTeamID, *flipped.GovTeamID)
+	})
+
+	t.Run("Edits Locked Once Ballots Exist", func(t *testing.T) {
+		set := models.BallotSet{MatchID: before[1].ID, AdjudicatorID: 1, Status: BallotDraft}
+		models.DB.Create(&set)
+		body := fmt.Sprintf(`{"match_id":%d,"version":%d}`, before[0].ID, roundVersion(round.ID))
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", roundPath+"/draw/flip-sides", body).Code)
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", roundPath+"/draw/undo", fmt.Sprintf(`{"version":%d}`, roundVersion(round.ID))).Code)
+		models.DB.Unscoped().Delete(&set)
+	})
+
+	t.Run("Edits Locked After Confirm", func(t *testing.T) {
+		status := func(drawStatus string) string {
+			return fmt.Sprintf(`{"draw_status":%q,"version":%d}`, drawStatus, roundVersion(round.ID))
+		}
+		assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", roundPath+"/draw-status", status("confirmed")).Code)
+		body := fmt.Sprintf(`{"match_id":%d,"version":%d}`, before[0].ID, roundVersion(round.ID))
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", roundPath+"/draw/flip-sides", body).Code)
+		assert.Equal(t, http.StatusBadRequest, sendJSON(router, "PUT", roundPath+"/draw-status", status("draft_x")).Code)
+		assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", roundPath+"/draw-status", status("released")).Code)
+	})
+}
+
+func TestDrawPreview(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Preview Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	for i, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot"} {
+		models.DB.Create(&models.Team{Name: name, TournamentID: tournament.ID, TotalVP: i % 2, TotalSpeaker: float64(150 + i)})
+	}
+	round := models.Round{Name: "Round 2", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	roundPath := "/api/rounds/" + strconv.Itoa(int(round.ID))
+
+	preview := func(body string) map[string]interface{} {
+		w := sendJSON(router, "POST", roundPath+"/draw/preview", body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	t.Run("Preview Does Not Persist", func(t *testing.T) {
+		response := preview(`{"pairing_method":"slide","pull_up_method":"bottom"}`)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, 3, len(data["pairings"].([]interface{})))
+		assert.Equal(t, 2, len(data["brackets"].([]interface{})))
+
+		var count int64
+		models.DB.Model(&models.Match{}).Where("round_id = ?", round.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Same Seed Same Draw", func(t *testing.T) {
+		a := preview(`{"pairing_method":"random","seed":7}`)
+		b := preview(`{"pairing_method":"random","seed":7}`)
+		assert.Equal(t, a["data"], b["data"])
 	})
+
+	t.Run("Diff Against Stored Draft", func(t *testing.T) {
+		w := sendJSON(router, "POST", roundPath+"/generate-draw", `{"pairing_method":"fold","version":1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		diff := preview(`{"pairing_method":"fold"}`)["diff"].(map[string]interface{})
+		assert.Equal(t, float64(3), diff["unchanged"])
+		assert.Equal(t, 0, len(diff["added"].([]interface{})))
+	})
+
+	t.Run("Invalid Method", func(t *testing.T) {
+		w := sendJSON(router, "POST", roundPath+"/draw/preview", `{"pairing_method":"zigzag"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRoomAllocation(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Room Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	top := models.Team{Name: "Top", TournamentID: tournament.ID, TotalVP: 2}
+	second := models.Team{Name: "Second", TournamentID: tournament.ID, TotalVP: 2}
+	wheelchair := models.Team{Name: "Wheelchair", TournamentID: tournament.ID, NeedsAccess: true}
+	bottom := models.Team{Name: "Bottom", TournamentID: tournament.ID}
+	for _, team := range []*models.Team{&top, &second, &wheelchair, &bottom} {
+		models.DB.Create(team)
+	}
+
+	grand := models.Room{Name: "Grand Hall", TournamentID: tournament.ID, Priority: 10}
+	ramp := models.Room{Name: "Ramp Room", TournamentID: tournament.ID, IsAccessible: true}
+	stairs := models.Room{Name: "Stairs Room", TournamentID: tournament.ID, Priority: 5}
+	for _, room := range []*models.Room{&grand, &ramp, &stairs} {
+		models.DB.Create(room)
+	}
+
+	round := models.Round{Name: "Round 3", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	liveMatch := models.Match{RoundID: round.ID, GovTeamID: &top.ID, OppTeamID: &second.ID}
+	accessMatch := models.Match{RoundID: round.ID, GovTeamID: &wheelchair.ID, OppTeamID: &bottom.ID}
+	models.DB.Create(&liveMatch)
+	models.DB.Create(&accessMatch)
+
+	w := sendJSON(router, "POST", "/api/rounds/"+strconv.Itoa(int(round.ID))+"/allocate-rooms", `{"version":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	models.DB.First(&liveMatch, liveMatch.ID)
+	models.DB.First(&accessMatch, accessMatch.ID)
+	assert.Equal(t, grand.ID, *liveMatch.RoomID)
+	assert.Equal(t, ramp.ID, *accessMatch.RoomID)
+}
+
+func TestRoundAvailability(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Availability Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	var teams []models.Team
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+		team := models.Team{Name: name, TournamentID: tournament.ID}
+		models.DB.Create(&team)
+		teams = append(teams, team)
+	}
+	round1 := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	round2 := models.Round{Name: "Round 2", TournamentID: tournament.ID}
+	models.DB.Create(&round1)
+	models.DB.Create(&round2)
+
+	body := fmt.Sprintf(`{"entity_type":"team","ids":[%d],"is_available":false}`, teams[4].ID)
+	w := sendJSON(router, "PUT", "/api/rounds/"+strconv.Itoa(int(round1.ID))+"/availability", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = sendJSON(router, "POST", "/api/rounds/"+strconv.Itoa(int(round1.ID))+"/generate-draw", `{"version":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var matches []models.Match
+	models.DB.Where("round_id = ?", round1.ID).Find(&matches)
+	assert.Equal(t, 2, len(matches)) // 4 tim tersedia, tanpa bye
+	for _, match := range matches {
+		assert.NotEqual(t, teams[4].ID, *match.GovTeamID)
+		assert.NotEqual(t, teams[4].ID, *match.OppTeamID)
+	}
+
+	// Ronde lain tidak terpengaruh
+	w = sendJSON(router, "GET", "/api/rounds/"+strconv.Itoa(int(round2.ID))+"/availability?entity_type=team", "")
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	for _, entry := range response["data"].([]interface{}) {
+		assert.True(t, entry.(map[string]interface{})["is_available"].(bool))
+	}
+}
+
+func TestAdjudicatorPanel(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Panel Cup", Format: "asian"}
+	other := models.Tournament{Name: "Other Cup", Slug: "other-cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&other)
+	var adjs []models.Adjudicator
+	for _, name := range []string{"Chair", "Wing A", "Wing B", "Trainee", "Busy"} {
+		adj := models.Adjudicator{Name: name, TournamentID: tournament.ID, IsAvailable: true}
+		models.DB.Create(&adj)
+		adjs = append(adjs, adj)
+	}
+	outsider := models.Adjudicator{Name: "Outsider", TournamentID: other.ID, IsAvailable: true}
+	models.DB.Create(&outsider)
+
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID}
+	otherMatch := models.Match{RoundID: round.ID}
+	models.DB.Create(&match)
+	models.DB.Create(&otherMatch)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: otherMatch.ID, AdjudicatorID: adjs[4].ID, Role: PanelRoleChair})
+
+	assign := func(body string) *httptest.ResponseRecorder {
+		body = strings.TrimSuffix(body, "}") + fmt.Sprintf(`,"version":%d}`, matchVersion(match.ID))
+		return sendJSON(router, "PUT", "/api/matches/"+strconv.Itoa(int(match.ID))+"/panel", body)
+	}
+
+	// Ukuran panel harus sesuai jumlah chair + wing
+	w := assign(fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d],"panel_size":3}`, adjs[0].ID, adjs[1].ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Juri dari turnamen lain ditolak
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d]}`, adjs[0].ID, outsider.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Juri yang sudah dipasang di match lain pada ronde yang sama ditolak
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d]}`, adjs[0].ID, adjs[4].ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d,%d],"trainee_adj_ids":[%d],"panel_size":3}`, adjs[0].ID, adjs[1].ID, adjs[2].ID, adjs[3].ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = sendJSON(router, "GET", "/api/matches?round_id="+strconv.Itoa(int(round.ID)), "")
+	var response struct {
+		Data []models.Match `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 2, len(response.Data))
+	panel := response.Data[0].Panel
+	assert.Equal(t, 4, len(panel))
+	assert.Equal(t, PanelRoleChair, panel[0].Role)
+	assert.Equal(t, "Chair", panel[0].Adjudicator.Name)
+	assert.Equal(t, PanelRoleTrainee, panel[3].Role)
+	assert.Equal(t, adjs[0].ID, *response.Data[0].AdjudicatorID)
+}
+
+func TestAdjudicatorConflicts(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Conflict Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	ugm := models.Team{Name: "UGM A", Institution: "UGM", TournamentID: tournament.ID}
+	ui := models.Team{Name: "UI A", Institution: "UI", TournamentID: tournament.ID}
+	models.DB.Create(&ugm)
+	models.DB.Create(&ui)
+	alumni := models.Adjudicator{Name: "Alumni", Institution: "ugm", TournamentID: tournament.ID, IsAvailable: true}
+	coach := models.Adjudicator{Name: "Coach", Institution: "ITB", TournamentID: tournament.ID, IsAvailable: true}
+	partner := models.Adjudicator{Name: "Partner", Institution: "ITS", TournamentID: tournament.ID, IsAvailable: true}
+	for _, adj := range []*models.Adjudicator{&alumni, &coach, &partner} {
+		models.DB.Create(adj)
+	}
+
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &ugm.ID, OppTeamID: &ui.ID}
+	models.DB.Create(&match)
+
+	body := `{"data":[["adjudicator","type","target","reason"],["Coach","team","UI A","Former coach"],["Coach","adjudicator","Partner","Partners"]]}`
+	w := sendJSON(router, "POST", "/api/adjudicator-conflicts/import-csv?tournament_id="+strconv.Itoa(int(tournament.ID)), body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var count int64
+	models.DB.Model(&models.AdjudicatorConflict{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	assign := func(body string) *httptest.ResponseRecorder {
+		body = strings.TrimSuffix(body, "}") + fmt.Sprintf(`,"version":%d}`, matchVersion(match.ID))
+		return sendJSON(router, "PUT", "/api/matches/"+strconv.Itoa(int(match.ID))+"/panel", body)
+	}
+
+	// Konflik institusi sendiri berlaku otomatis
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d}`, alumni.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Konflik tim yang diimpor
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d}`, coach.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Konflik antar juri
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d]}`, partner.ID, coach.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d}`, partner.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Override tetap bisa, tapi ditandai saat validasi draw
+	w = assign(fmt.Sprintf(`{"chief_adj_id":%d,"allow_conflict":true}`, alumni.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = sendJSON(router, "GET", "/api/rounds/"+strconv.Itoa(int(round.ID))+"/draw/validate", "")
+	var response struct {
+		Data []drawIssue `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	flagged := false
+	for _, issue := range response.Data {
+		if issue.MatchID == match.ID && issue.TeamID == ugm.ID && issue.Severity == "warning" {
+			flagged = true
+		}
+	}
+	assert.True(t, flagged)
+}
+
+func TestAdjudicatorAllocation(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Allocation Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	top1 := models.Team{Name: "Top 1", Institution: "UGM", TournamentID: tournament.ID, TotalVP: 2}
+	top2 := models.Team{Name: "Top 2", Institution: "UI", TournamentID: tournament.ID, TotalVP: 2}
+	low1 := models.Team{Name: "Low 1", Institution: "ITB", TournamentID: tournament.ID}
+	low2 := models.Team{Name: "Low 2", Institution: "ITS", TournamentID: tournament.ID}
+	for _, team := range []*models.Team{&top1, &top2, &low1, &low2} {
+		models.DB.Create(team)
+	}
+	best := models.Adjudicator{Name: "Best", Institution: "UGM", Score: 9, TournamentID: tournament.ID, IsAvailable: true}
+	good := models.Adjudicator{Name: "Good", Score: 8, TournamentID: tournament.ID, IsAvailable: true}
+	okay := models.Adjudicator{Name: "Okay", Score: 6, TournamentID: tournament.ID, IsAvailable: true}
+	weak := models.Adjudicator{Name: "Weak", Score: 4, TournamentID: tournament.ID, IsAvailable: true}
+	for _, adj := range []*models.Adjudicator{&best, &good, &okay, &weak} {
+		models.DB.Create(adj)
+	}
+
+	// Okay pernah menilai Top 2 di ronde sebelumnya
+	round1 := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round1)
+	old := models.Match{RoundID: round1.ID, GovTeamID: &top2.ID, OppTeamID: &low1.ID}
+	models.DB.Create(&old)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: old.ID, AdjudicatorID: okay.ID, Role: PanelRoleChair})
+
+	round2 := models.Round{Name: "Round 2", TournamentID: tournament.ID}
+	models.DB.Create(&round2)
+	topMatch := models.Match{RoundID: round2.ID, GovTeamID: &top1.ID, OppTeamID: &top2.ID}
+	lowMatch := models.Match{RoundID: round2.ID, GovTeamID: &low1.ID, OppTeamID: &low2.ID}
+	models.DB.Create(&topMatch)
+	models.DB.Create(&lowMatch)
+	// Trainee yang dipasang manual tetap ada setelah alokasi ulang
+	trainee := models.Adjudicator{Name: "Trainee", Score: 10, TournamentID: tournament.ID, IsAvailable: true}
+	models.DB.Create(&trainee)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: lowMatch.ID, AdjudicatorID: trainee.ID, Role: PanelRoleTrainee})
+
+	allocateURL := "/api/rounds/" + strconv.Itoa(int(round2.ID)) + "/allocate-adjudicators"
+	allocate := func() *httptest.ResponseRecorder {
+		return sendJSON(router, "POST", allocateURL, fmt.Sprintf(`{"panel_size":2,"version":%d}`, roundVersion(round2.ID)))
+	}
+	assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "POST", allocateURL, `{"panel_size":2}`).Code)
+	assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", allocateURL, `{"panel_size":2,"version":9}`).Code)
+	w := allocate()
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	panels, _ := loadRoundPanels(models.DB, round2.ID)
+	// Best berkonflik dengan UGM (institusi sendiri) sehingga chair debat teratas adalah Good
+	assert.Equal(t, good.ID, panels[topMatch.ID][0].AdjudicatorID)
+	assert.Equal(t, PanelRoleChair, panels[topMatch.ID][0].Role)
+	assert.Equal(t, best.ID, panels[lowMatch.ID][0].AdjudicatorID)
+	// Okay sudah pernah menilai Top 2, jadi panellist debat teratas adalah Weak
+	assert.Equal(t, weak.ID, panels[topMatch.ID][1].AdjudicatorID)
+	assert.Equal(t, okay.ID, panels[lowMatch.ID][1].AdjudicatorID)
+	assert.Len(t, panels[lowMatch.ID], 3)
+	assert.Equal(t, trainee.ID, panels[lowMatch.ID][2].AdjudicatorID)
+	assert.Equal(t, PanelRoleTrainee, panels[lowMatch.ID][2].Role)
+
+	models.DB.First(&topMatch, topMatch.ID)
+	assert.Equal(t, good.ID, *topMatch.AdjudicatorID)
+
+	// Draw lama yang masih draft tetapi sudah punya ballot tidak dialokasikan ulang
+	ballot := models.BallotSet{MatchID: lowMatch.ID, AdjudicatorID: best.ID, Winner: "gov", Status: BallotConfirmed}
+	models.DB.Create(&ballot)
+	version := roundVersion(round2.ID)
+	assert.Equal(t, http.StatusConflict, allocate().Code)
+	assert.Equal(t, version, roundVersion(round2.ID))
+	panels, _ = loadRoundPanels(models.DB, round2.ID)
+	assert.Equal(t, best.ID, panels[lowMatch.ID][0].AdjudicatorID)
+	models.DB.Delete(&ballot)
+
+	// Setelah draw dikonfirmasi, panel tidak boleh dialokasikan ulang
+	models.DB.Model(&round2).Update("draw_status", DrawStatusConfirmed)
+	assert.Equal(t, http.StatusConflict, allocate().Code)
+}
+
+func TestAllocationDiagnostics(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Diagnostics Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	alpha := models.Team{Name: "Alpha", Institution: "UGM", TournamentID: tournament.ID, TotalVP: 2}
+	beta := models.Team{Name: "Beta", Institution: "UI", TournamentID: tournament.ID, TotalVP: 1}
+	gamma := models.Team{Name: "Gamma", Institution: "ITB", TournamentID: tournament.ID}
+	delta := models.Team{Name: "Delta", Institution: "ITS", TournamentID: tournament.ID}
+	for _, team := range []*models.Team{&alpha, &beta, &gamma, &delta} {
+		models.DB.Create(team)
+	}
+	chair := models.Adjudicator{Name: "Chair", Score: 8, TournamentID: tournament.ID, IsAvailable: true}
+	wing := models.Adjudicator{Name: "Wing", Institution: "UGM", Score: 6, TournamentID: tournament.ID, IsAvailable: true}
+	trainee := models.Adjudicator{Name: "Trainee", Score: 3, TournamentID: tournament.ID, IsAvailable: true}
+	repeat := models.Adjudicator{Name: "Repeat", Score: 7, TournamentID: tournament.ID, IsAvailable: true}
+	idle := models.Adjudicator{Name: "Idle", Score: 5, TournamentID: tournament.ID, IsAvailable: true}
+	for _, adj := range []*models.Adjudicator{&chair, &wing, &trainee, &repeat, &idle} {
+		models.DB.Create(adj)
+	}
+
+	// Repeat sudah menilai Gamma vs Delta di ronde 1
+	round1 := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round1)
+	old := models.Match{RoundID: round1.ID, GovTeamID: &gamma.ID, OppTeamID: &delta.ID}
+	models.DB.Create(&old)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: old.ID, AdjudicatorID: repeat.ID, Role: PanelRoleChair})
+
+	// Panel dipasang manual: Wing (institusi UGM) di-override ke debat Alpha
+	round2 := models.Round{Name: "Round 2", TournamentID: tournament.ID}
+	models.DB.Create(&round2)
+	top := models.Match{RoundID: round2.ID, GovTeamID: &alpha.ID, OppTeamID: &beta.ID, Importance: 2}
+	rematch := models.Match{RoundID: round2.ID, GovTeamID: &gamma.ID, OppTeamID: &delta.ID}
+	models.DB.Create(&top)
+	models.DB.Create(&rematch)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: top.ID, AdjudicatorID: chair.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: top.ID, AdjudicatorID: wing.ID, Role: PanelRolePanellist})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: top.ID, AdjudicatorID: trainee.ID, Role: PanelRoleTrainee})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: rematch.ID, AdjudicatorID: repeat.ID, Role: PanelRoleChair})
+
+	w := sendJSON(router, "GET", "/api/rounds/"+strconv.Itoa(int(round2.ID))+"/allocation-diagnostics", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var diagnostics struct {
+		Data         []matchDiagnostics   `json:"data"`
+		Unused       []models.Adjudicator `json:"unused"`
+		Adjudicators []adjudicatorLoad    `json:"adjudicators"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &diagnostics)
+	assert.Len(t, diagnostics.Data, 2)
+
+	// Trainee tidak dihitung di kekuatan & ukuran panel
+	first := diagnostics.Data[0]
+	assert.Equal(t, top.ID, first.MatchID)
+	assert.Equal(t, 3, first.Bracket)
+	assert.Equal(t, 2, first.Importance)
+	assert.Equal(t, chair.ID, first.ChairID)
+	assert.Equal(t, 8.0, first.ChairScore)
+	assert.Equal(t, 2, first.PanelSize)
+	assert.Equal(t, 7.0, first.PanelStrength) // (Chair 8 + Wing 6) / 2
+	assert.Len(t, first.Conflicts, 1)
+	assert.Equal(t, wing.ID, first.Conflicts[0].AdjudicatorID)
+	assert.Equal(t, alpha.ID, first.Conflicts[0].TeamID)
+	assert.Empty(t, first.SeenBefore)
+
+	second := diagnostics.Data[1]
+	assert.Equal(t, repeat.ID, second.ChairID)
+	assert.Equal(t, 1, second.PanelSize)
+	assert.Empty(t, second.Conflicts)
+	assert.Len(t, second.SeenBefore, 1)
+	assert.Equal(t, repeat.ID, second.SeenBefore[0].AdjudicatorID)
+	assert.ElementsMatch(t, []uint{gamma.ID, delta.ID}, second.SeenBefore[0].TeamIDs)
+
+	assert.Len(t, diagnostics.Unused, 1)
+	assert.Equal(t, idle.ID, diagnostics.Unused[0].ID)
+
+	// Beban juri sepanjang turnamen (termasuk ronde 1)
+	loads := make(map[uint]adjudicatorLoad)
+	for _, load := range diagnostics.Adjudicators {
+		loads[load.AdjudicatorID] = load
+	}
+	assert.Len(t, loads, 5)
+	assert.Equal(t, 2, loads[repeat.ID].Chaired)
+	assert.Equal(t, 1, loads[chair.ID].Chaired)
+	assert.Equal(t, 1, loads[wing.ID].Panelled)
+	assert.Equal(t, 1, loads[trainee.ID].Trainee)
+	assert.Equal(t, adjudicatorLoad{AdjudicatorID: idle.ID, Name: "Idle", Score: 5}, loads[idle.ID])
+
+	w = sendJSON(router, "GET", "/api/rounds/999/allocation-diagnostics", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdjudicatorScores(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Score Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, ByeStrategy: ByeStrategyWin, FeedbackWeight: 0.5, FeedbackFullWeightAfter: 2})
+
+	var created struct {
+		Data models.Adjudicator `json:"data"`
+	}
+	w := sendJSON(router, "POST", "/api/adjudicators", fmt.Sprintf(`{"name":"Tested","tournament_id":%d,"base_score":6}`, tournament.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &created)
+	adj := created.Data
+	assert.Equal(t, 6.0, adj.Score)
+	other := models.Adjudicator{Name: "Other", TournamentID: tournament.ID, BaseScore: 7, Score: 7}
+	models.DB.Create(&other)
+
+	// Satu match dinilai: bobot feedback baru setengah dari 0.5
+	w = sendJSON(router, "POST", "/api/adjudicator-feedback", fmt.Sprintf(`{"match_id":1,"tournament_id":%d,"adjudicator_id":%d,"team_role":"gov","rating":5}`, tournament.ID, adj.ID))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	models.DB.First(&adj, adj.ID)
+	assert.Equal(t, 7.0, adj.Score)
+
+	w = sendJSON(router, "POST", "/api/adjudicator-feedback", fmt.Sprintf(`{"match_id":2,"tournament_id":%d,"adjudicator_id":%d,"team_role":"gov","rating":5}`, tournament.ID, adj.ID))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	models.DB.First(&adj, adj.ID)
+	assert.Equal(t, 8.0, adj.Score)
+
+	w = sendJSON(router, "PUT", "/api/adjudicators/"+strconv.Itoa(int(adj.ID))+"/score", `{"score_override":5,"note":"Poor chairing"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&adj, adj.ID)
+	assert.Equal(t, 5.0, adj.Score)
+
+	w = sendJSON(router, "GET", "/api/adjudicators/ranking?tournament_id="+strconv.Itoa(int(tournament.ID)), "")
+	var ranking struct {
+		Data []struct {
+			Rank          int  `json:"rank"`
+			AdjudicatorID uint `json:"adjudicator_id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &ranking)
+	assert.Equal(t, other.ID, ranking.Data[0].AdjudicatorID)
+	assert.Equal(t, adj.ID, ranking.Data[1].AdjudicatorID)
+
+	w = sendJSON(router, "PUT", "/api/adjudicators/"+strconv.Itoa(int(adj.ID))+"/score", `{"clear_override":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&adj, adj.ID)
+	assert.Equal(t, 8.0, adj.Score)
+
+	w = sendJSON(router, "GET", "/api/adjudicators/"+strconv.Itoa(int(adj.ID))+"/score-history", "")
+	var history struct {
+		Data []models.AdjudicatorScoreHistory `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &history)
+	assert.Equal(t, 5, len(history.Data))
+	assert.Equal(t, "override", history.Data[0].Source)
+	assert.Equal(t, "Poor chairing", history.Data[1].Note)
+
+	// Rating 1 = 0 di skala juri: rata-rata (10 + 10 + 0) / 3
+	w = sendJSON(router, "POST", "/api/adjudicator-feedback", fmt.Sprintf(`{"match_id":3,"tournament_id":%d,"adjudicator_id":%d,"team_role":"gov","rating":1,"comment":"Late"}`, tournament.ID, adj.ID))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	models.DB.First(&adj, adj.ID)
+	assert.Equal(t, 6.33, adj.Score)
+}
+
+func TestTraineeBallots(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Trainee Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, SpeakersPerSide: 1})
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	govPM := models.Speaker{Name: "PM", TeamID: gov.ID}
+	oppLO := models.Speaker{Name: "LO", TeamID: opp.ID}
+	models.DB.Create(&govPM)
+	models.DB.Create(&oppLO)
+
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID}
+	models.DB.Create(&match)
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	trainee := models.Adjudicator{Name: "Trainee", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	models.DB.Create(&trainee)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: chair.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: trainee.ID, Role: PanelRoleTrainee})
+
+	submit := func(adjID uint, winner string, govScore, oppScore float64) *httptest.ResponseRecorder {
+		return sendJSON(router, "POST", "/api/submit-ballot", ballotBody(match.ID, adjID, matchVersion(match.ID), winner, []ballotScore{
+			{SpeakerID: govPM.ID, Name: "PM", Score: govScore, Position: "PM", TeamRole: "gov"},
+			{SpeakerID: oppLO.ID, Name: "LO", Score: oppScore, Position: "LO", TeamRole: "opp"},
+		}))
+	}
+
+	// Trainee lebih dulu submit: tidak mengubah hasil match
+	w := submit(trainee.ID, "opp", 74, 76)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.False(t, match.IsCompleted)
+
+	w = submit(chair.ID, "gov", 78, 75)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	models.DB.First(&gov, gov.ID)
+	models.DB.First(&opp, opp.ID)
+	assert.Equal(t, 1, gov.TotalVP)
+	assert.Equal(t, 78.0, gov.TotalSpeaker)
+	assert.Equal(t, 75.0, opp.TotalSpeaker)
+
+	// Resubmit chair tidak menghapus ballot trainee
+	w = submit(chair.ID, "gov", 78, 75)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var traineeCount int64
+	models.DB.Model(&models.Ballot{}).Where("is_trainee = ?", true).Count(&traineeCount)
+	assert.Equal(t, int64(2), traineeCount)
+
+	w = sendJSON(router, "GET", "/api/trainee-report?round_id="+strconv.Itoa(int(round.ID)), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report struct {
+		Data    []traineeComparison `json:"data"`
+		Summary []traineeSummary    `json:"summary"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Equal(t, 1, len(report.Data))
+	assert.Equal(t, "opp", report.Data[0].TraineeWinner)
+	assert.Equal(t, "gov", report.Data[0].PanelWinner)
+	assert.False(t, report.Data[0].AgreesWithPanel)
+	assert.Equal(t, 2.5, report.Data[0].AvgScoreDiff) // (|74-78| + |76-75|) / 2
+	assert.Equal(t, 0.0, report.Summary[0].AgreementRate)
+}
+
+func TestCheckIn(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Check-in Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, ByeStrategy: ByeStrategyWin, RequireCheckIn: true})
+	var teams []models.Team
+	var speakers []models.Speaker
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+		team := models.Team{Name: name, TournamentID: tournament.ID}
+		models.DB.Create(&team)
+		teams = append(teams, team)
+		for i := 1; i <= 2; i++ {
+			speaker := models.Speaker{Name: fmt.Sprintf("%s %d", name, i), TeamID: team.ID}
+			models.DB.Create(&speaker)
+			speakers = append(speakers, speaker)
+		}
+	}
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	roundURL := "/api/rounds/" + strconv.Itoa(int(round.ID))
+
+	// Alpha-Delta check-in manual per tim
+	w := sendJSON(router, "PUT", roundURL+"/check-ins", fmt.Sprintf(`{"entity_type":"team","ids":[%d,%d,%d,%d],"checked_in":true}`, teams[0].ID, teams[1].ID, teams[2].ID, teams[3].ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Echo: hanya satu speaker yang scan QR, tim belum lengkap
+	w = sendJSON(router, "GET", "/api/speakers/"+strconv.Itoa(int(speakers[8].ID))+"/check-in-code", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var codeResponse struct {
+		Data struct {
+			Code string `json:"code"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &codeResponse)
+	w = sendJSON(router, "POST", roundURL+"/check-ins/scan", fmt.Sprintf(`{"code":"%s"}`, codeResponse.Data.Code))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "POST", roundURL+"/check-ins/scan", `{"code":"EDS-CHECKIN:s:unknown"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = sendJSON(router, "GET", roundURL+"/check-ins?entity_type=team", "")
+	var teamStatus struct {
+		CheckedIn int `json:"checked_in"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &teamStatus)
+	assert.Equal(t, 4, teamStatus.CheckedIn)
+
+	// Draw hanya memakai tim yang sudah check-in
+	w = sendJSON(router, "POST", roundURL+"/generate-draw", `{"version":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var matches []models.Match
+	models.DB.Where("round_id = ?", round.ID).Find(&matches)
+	assert.Equal(t, 2, len(matches))
+	for _, match := range matches {
+		assert.NotEqual(t, teams[4].ID, *match.GovTeamID)
+		assert.NotEqual(t, teams[4].ID, *match.OppTeamID)
+	}
+}
+
+func TestParticipantUpdates(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Edit Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	other := models.Tournament{Name: "Other Cup", Slug: "other-cup", Format: "asian"}
+	models.DB.Create(&other)
+	alpha := models.Team{Name: "Alpha", TournamentID: tournament.ID}
+	bravo := models.Team{Name: "Bravo", TournamentID: tournament.ID}
+	foreign := models.Team{Name: "Foreign", TournamentID: other.ID}
+	models.DB.Create(&alpha)
+	models.DB.Create(&bravo)
+	models.DB.Create(&foreign)
+	scored := models.Speaker{Name: "Scored", TeamID: alpha.ID}
+	models.DB.Create(&scored)
+	adj := models.Adjudicator{Name: "Judge", TournamentID: tournament.ID}
+	idle := models.Adjudicator{Name: "Idle", TournamentID: tournament.ID}
+	models.DB.Create(&adj)
+	models.DB.Create(&idle)
+	room := models.Room{Name: "A1", TournamentID: tournament.ID}
+	models.DB.Create(&room)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &bravo.ID, OppTeamID: &foreign.ID, AdjudicatorID: &idle.ID}
+	models.DB.Create(&match)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: idle.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.Ballot{MatchID: match.ID, AdjudicatorID: adj.ID, SpeakerID: scored.ID, Score: 75})
+
+	id := func(v uint) string { return strconv.Itoa(int(v)) }
+
+	// Team
+	w := sendJSON(router, "PUT", "/api/teams/"+id(alpha.ID), `{"name":"Alpha A","institution":"UGM"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "PUT", "/api/teams/"+id(alpha.ID), `{"name":"bravo"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "PUT", "/api/teams/"+id(alpha.ID), `{"name":"  "}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Speaker: tambah, ganti nama, pindah tim, hapus
+	w = sendJSON(router, "POST", "/api/teams/"+id(alpha.ID)+"/speakers", `{"name":"Newbie"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var created struct {
+		Data models.Speaker `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	w = sendJSON(router, "POST", "/api/teams/"+id(alpha.ID)+"/speakers", `{"name":"newbie"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+id(created.Data.ID), `{"name":"Newcomer"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+id(created.Data.ID), fmt.Sprintf(`{"team_id":%d}`, foreign.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+id(created.Data.ID), fmt.Sprintf(`{"team_id":%d}`, bravo.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var moved models.Speaker
+	models.DB.First(&moved, created.Data.ID)
+	assert.Equal(t, bravo.ID, moved.TeamID)
+	assert.Equal(t, "Newcomer", moved.Name)
+	w = sendJSON(router, "DELETE", "/api/speakers/"+id(created.Data.ID), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Speaker dengan ballot: boleh ganti nama, tidak boleh pindah / dihapus
+	w = sendJSON(router, "PUT", "/api/speakers/"+id(scored.ID), `{"name":"Scored Speaker"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+id(scored.ID), fmt.Sprintf(`{"team_id":%d}`, bravo.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "DELETE", "/api/speakers/"+id(scored.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "DELETE", "/api/teams/"+id(alpha.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Adjudicator
+	w = sendJSON(router, "PUT", "/api/adjudicators/"+id(adj.ID), `{"name":"Judge Judy","is_available":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updatedAdj models.Adjudicator
+	models.DB.First(&updatedAdj, adj.ID)
+	assert.Equal(t, "Judge Judy", updatedAdj.Name)
+	assert.False(t, updatedAdj.IsAvailable)
+	w = sendJSON(router, "DELETE", "/api/adjudicators/"+id(adj.ID), "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "DELETE", "/api/adjudicators/"+id(idle.ID), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var unassigned models.Match
+	models.DB.First(&unassigned, match.ID)
+	assert.Nil(t, unassigned.AdjudicatorID)
+	var panelRows int64
+	models.DB.Model(&models.MatchAdjudicator{}).Where("match_id = ?", match.ID).Count(&panelRows)
+	assert.Equal(t, int64(0), panelRows)
+
+	// Room
+	w = sendJSON(router, "PUT", "/api/rooms/"+id(room.ID), `{"capacity":-1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendJSON(router, "PUT", "/api/rooms/"+id(room.ID), `{"name":"A2","is_accessible":true,"priority":3}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updatedRoom models.Room
+	models.DB.First(&updatedRoom, room.ID)
+	assert.Equal(t, "A2", updatedRoom.Name)
+	assert.True(t, updatedRoom.IsAccessible)
+	assert.Equal(t, 3, updatedRoom.Priority)
+}
+
+func TestTeamWithdrawal(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Withdrawal Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, ByeStrategy: ByeStrategyWin, ForfeitWinPoints: 2, ForfeitSpeakerScores: ForfeitSpeakerNone})
+	var teams []models.Team
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta"} {
+		team := models.Team{Name: name, TournamentID: tournament.ID}
+		models.DB.Create(&team)
+		teams = append(teams, team)
+	}
+	alpha, bravo, charlie, delta := teams[0], teams[1], teams[2], teams[3]
+	round1 := models.Round{Name: "Round 1", TournamentID: tournament.ID, DrawStatus: DrawStatusReleased}
+	round2 := models.Round{Name: "Round 2", TournamentID: tournament.ID, DrawStatus: DrawStatusReleased}
+	round3 := models.Round{Name: "Round 3", TournamentID: tournament.ID, DrawStatus: DrawStatusDraft}
+	models.DB.Create(&round1)
+	models.DB.Create(&round2)
+	models.DB.Create(&round3)
+	models.DB.Create(&models.Match{RoundID: round1.ID, GovTeamID: &alpha.ID, OppTeamID: &bravo.ID, WinnerID: &alpha.ID, IsCompleted: true})
+	models.DB.Create(&models.Match{RoundID: round1.ID, GovTeamID: &charlie.ID, OppTeamID: &delta.ID, WinnerID: &charlie.ID, IsCompleted: true})
+	forfeit := models.Match{RoundID: round2.ID, GovTeamID: &alpha.ID, OppTeamID: &charlie.ID}
+	models.DB.Create(&forfeit)
+	models.DB.Create(&models.Match{RoundID: round2.ID, GovTeamID: &bravo.ID, OppTeamID: &delta.ID})
+	models.DB.Create(&models.Match{RoundID: round3.ID, GovTeamID: &charlie.ID, OppTeamID: &delta.ID})
+
+	withdrawURL := "/api/teams/" + strconv.Itoa(int(charlie.ID)) + "/withdraw"
+
+	w := sendJSON(router, "POST", withdrawURL, `{"round_id":9999}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = sendJSON(router, "POST", withdrawURL, fmt.Sprintf(`{"round_id":%d,"reason":"Flight home"}`, round2.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data              models.Team `json:"data"`
+		ForfeitedMatches  []uint      `json:"forfeited_matches"`
+		DrawsToRegenerate []uint      `json:"draws_to_regenerate"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.True(t, response.Data.Withdrawn)
+	assert.Equal(t, []uint{forfeit.ID}, response.ForfeitedMatches)
+	assert.Equal(t, []uint{round3.ID}, response.DrawsToRegenerate)
+
+	w = sendJSON(router, "POST", withdrawURL, fmt.Sprintf(`{"round_id":%d}`, round2.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Match forfeit: lawan menang, tidak bisa di-ballot
+	var updated models.Match
+	models.DB.First(&updated, forfeit.ID)
+	assert.True(t, updated.IsForfeit)
+	assert.True(t, updated.IsCompleted)
+	assert.Equal(t, alpha.ID, *updated.WinnerID)
+	w = sendJSON(router, "POST", "/api/submit-ballot", fmt.Sprintf(`{"match_id":%d,"winner":"gov","scores":[]}`, forfeit.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Standings: Alpha 1 + 2 VP forfeit, Charlie tetap tampil dengan tanda mundur
+	var alphaStats, charlieStats models.Team
+	models.DB.First(&alphaStats, alpha.ID)
+	models.DB.First(&charlieStats, charlie.ID)
+	assert.Equal(t, 3, alphaStats.TotalVP)
+	assert.Equal(t, 2, alphaStats.Wins)
+	assert.Equal(t, 1, charlieStats.TotalVP)
+	assert.Equal(t, 1, charlieStats.Losses)
+
+	w = sendJSON(router, "GET", "/api/standings/teams?tournament_id="+strconv.Itoa(int(tournament.ID)), "")
+	var standings struct {
+		Data []models.Team `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &standings)
+	found := false
+	for _, team := range standings.Data {
+		if team.ID == charlie.ID {
+			found = true
+			assert.True(t, team.Withdrawn)
+		}
+	}
+	assert.True(t, found)
+
+	// Tidak ikut draw mulai ronde efektif
+	w = sendJSON(router, "GET", "/api/rounds/"+strconv.Itoa(int(round1.ID))+"/availability?entity_type=team", "")
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"entity_id":%d,"name":"Charlie","is_available":true`, charlie.ID))
+	w = sendJSON(router, "GET", "/api/rounds/"+strconv.Itoa(int(round3.ID))+"/availability?entity_type=team", "")
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"entity_id":%d,"name":"Charlie","is_available":false`, charlie.ID))
+	models.DB.Where("round_id = ?", round3.ID).Delete(&models.Match{})
+	w = sendJSON(router, "POST", "/api/rounds/"+strconv.Itoa(int(round3.ID))+"/generate-draw", fmt.Sprintf(`{"version":%d}`, roundVersion(round3.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var drawn []models.Match
+	models.DB.Where("round_id = ?", round3.ID).Find(&drawn)
+	for _, match := range drawn {
+		assert.NotEqual(t, charlie.ID, *match.GovTeamID)
+		if match.OppTeamID != nil {
+			assert.NotEqual(t, charlie.ID, *match.OppTeamID)
+		}
+	}
+}
+
+func TestSpeakerCategories(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Category Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	scores := map[string]int{"Alpha": 300, "Bravo": 200, "Charlie": 100}
+	speakerIDs := make(map[string]uint)
+	for _, name := range []string{"Alpha", "Bravo", "Charlie"} {
+		team := models.Team{Name: name, TournamentID: tournament.ID, TotalSpeaker: float64(scores[name])}
+		models.DB.Create(&team)
+		for i := 1; i <= 2; i++ {
+			speaker := models.Speaker{Name: fmt.Sprintf("%s %d", name, i), TeamID: team.ID, TotalScore: float64(scores[name]/2 - i)}
+			models.DB.Create(&speaker)
+			speakerIDs[speaker.Name] = speaker.ID
+		}
+	}
+
+	tid := strconv.Itoa(int(tournament.ID))
+
+	w := sendJSON(router, "POST", "/api/speaker-categories", fmt.Sprintf(`{"tournament_id":%d,"name":"Novice"}`, tournament.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var novice struct {
+		Data models.SpeakerCategory `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &novice)
+	w = sendJSON(router, "POST", "/api/speaker-categories", fmt.Sprintf(`{"tournament_id":%d,"name":"ESL"}`, tournament.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "POST", "/api/speaker-categories", fmt.Sprintf(`{"tournament_id":%d,"name":"novice"}`, tournament.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Bravo: dua speaker novice (eligible), Charlie: satu novice saja (tidak eligible)
+	w = sendJSON(router, "POST", "/api/speaker-categories/import-csv?tournament_id="+tid, `{"data":[["team","speaker","categories"],["Bravo","Bravo 1","Novice;ESL"],["Bravo","Bravo 2","novice"],["Charlie","Charlie 1","Novice"]]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "POST", "/api/speaker-categories/import-csv?tournament_id="+tid, `{"data":[["Alpha","Alpha 1","Pro"]]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+strconv.Itoa(int(speakerIDs["Alpha 1"]))+"/categories", fmt.Sprintf(`{"category_ids":[%d]}`, novice.Data.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "PUT", "/api/speakers/"+strconv.Itoa(int(speakerIDs["Alpha 1"]))+"/categories", `{"category_ids":[9999]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = sendJSON(router, "GET", "/api/speaker-categories?tournament_id="+tid, "")
+	var categories struct {
+		Data []struct {
+			Name            string `json:"name"`
+			SpeakerCount    int    `json:"speaker_count"`
+			EligibleTeamIDs []uint `json:"eligible_team_ids"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &categories)
+	assert.Len(t, categories.Data, 2)
+	for _, category := range categories.Data {
+		if category.Name == "Novice" {
+			assert.Equal(t, 4, category.SpeakerCount)
+			assert.Len(t, category.EligibleTeamIDs, 1)
+		}
+	}
+
+	categoryQuery := "?tournament_id=" + tid + "&category_id=" + strconv.Itoa(int(novice.Data.ID))
+	w = sendJSON(router, "GET", "/api/standings/teams"+categoryQuery, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var teamStandings struct {
+		Data []models.Team `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &teamStandings)
+	assert.Len(t, teamStandings.Data, 1)
+	assert.Equal(t, "Bravo", teamStandings.Data[0].Name)
+	assert.Equal(t, 1, teamStandings.Data[0].Rank)
+
+	w = sendJSON(router, "GET", "/api/standings/speakers"+categoryQuery, "")
+	var speakerStandings struct {
+		Data []models.Speaker `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &speakerStandings)
+	var names []string
+	for _, speaker := range speakerStandings.Data {
+		names = append(names, speaker.Name)
+	}
+	assert.Equal(t, []string{"Alpha 1", "Bravo 1", "Bravo 2", "Charlie 1"}, names)
+	assert.Equal(t, 1, speakerStandings.Data[0].SpeakerRank)
+
+	w = sendJSON(router, "GET", "/api/standings/speakers?category_id=9999", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPanelBallots(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Panel Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, SpeakersPerSide: 2})
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	pm := models.Speaker{Name: "PM", TeamID: gov.ID}
+	dpm := models.Speaker{Name: "DPM", TeamID: gov.ID}
+	lo := models.Speaker{Name: "LO", TeamID: opp.ID}
+	dlo := models.Speaker{Name: "DLO", TeamID: opp.ID}
+	for _, speaker := range []*models.Speaker{&pm, &dpm, &lo, &dlo} {
+		models.DB.Create(speaker)
+	}
+	var adjs []models.Adjudicator
+	for _, name := range []string{"Chair", "Wing 1", "Wing 2", "Outsider"} {
+		adj := models.Adjudicator{Name: name, TournamentID: tournament.ID}
+		models.DB.Create(&adj)
+		adjs = append(adjs, adj)
+	}
+	chair, wing1, wing2, outsider := adjs[0], adjs[1], adjs[2], adjs[3]
+
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: chair.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: wing1.ID, Role: PanelRolePanellist})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: wing2.ID, Role: PanelRolePanellist})
+
+	// version: versi match yang dimuat perangkat juri saat draw dirilis, tidak dimuat ulang
+	submit := func(matchID, adjID uint, version int, winner string, scores [4]float64, extra ...string) *httptest.ResponseRecorder {
+		return sendJSON(router, "POST", "/api/submit-ballot", ballotBody(matchID, adjID, version, winner, []ballotScore{
+			{SpeakerID: pm.ID, Score: scores[0], Position: "PM", TeamRole: "gov"},
+			{SpeakerID: dpm.ID, Score: scores[1], Position: "DPM", TeamRole: "gov"},
+			{SpeakerID: lo.ID, Score: scores[2], Position: "LO", TeamRole: "opp"},
+			{SpeakerID: dlo.ID, Score: scores[3], Position: "DLO", TeamRole: "opp"},
+		}, extra...))
+	}
+
+	// Ketiga juri memakai versi yang sama; ballot yang masuk lebih dulu tidak membuat yang lain 409
+	loaded := match.Version
+	w := submit(match.ID, chair.ID, loaded, "gov", [4]float64{76, 75, 74, 73})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = submit(match.ID, wing1.ID, loaded, "opp", [4]float64{73, 72, 75, 76})
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.False(t, match.IsCompleted)
+	assert.Nil(t, match.WinnerID)
+
+	w = submit(match.ID, outsider.ID, loaded, "gov", [4]float64{75, 75, 75, 74})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = submit(match.ID, wing2.ID, loaded, "gov", [4]float64{78, 77, 74, 75})
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.True(t, match.IsCompleted)
+	assert.Equal(t, gov.ID, *match.WinnerID)
+
+	// Skor dirata-rata dari ballot mayoritas (chair & wing 2)
+	models.DB.First(&gov, gov.ID)
+	models.DB.First(&opp, opp.ID)
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 1, gov.TotalVP)
+	assert.Equal(t, 153.0, gov.TotalSpeaker)
+	assert.Equal(t, 148.0, opp.TotalSpeaker)
+	assert.Equal(t, 77.0, pm.TotalScore)
+
+	// Resubmit wing 1 menggantikan ballot-nya sendiri saja, dengan versi match terkini
+	w = submit(match.ID, wing1.ID, loaded, "gov", [4]float64{74, 74, 73, 73})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	// base_revision dibandingkan dengan ballot wing 1 sendiri (revisi 2), bukan revisi match (3)
+	w = submit(match.ID, wing1.ID, matchVersion(match.ID), "gov", [4]float64{74, 74, 73, 73}, `"base_revision":0`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = submit(match.ID, wing1.ID, matchVersion(match.ID), "gov", [4]float64{74, 74, 73, 73}, `"base_revision":2`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var setCount int64
+	models.DB.Model(&models.BallotSet{}).Where("match_id = ? AND status <> ?", match.ID, BallotDiscarded).Count(&setCount)
+	assert.Equal(t, int64(3), setCount)
+
+	// Panel 2 juri, suara seri: chair memutuskan, atau total skor sesuai settings
+	splitRound := models.Round{Name: "Round 2", TournamentID: tournament.ID}
+	models.DB.Create(&splitRound)
+	split := models.Match{RoundID: splitRound.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&split)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: split.ID, AdjudicatorID: chair.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: split.ID, AdjudicatorID: wing1.ID, Role: PanelRolePanellist})
+	submit(split.ID, chair.ID, split.Version, "opp", [4]float64{75, 75, 75, 76})
+	submit(split.ID, wing1.ID, split.Version, "gov", [4]float64{79, 78, 74, 74})
+	models.DB.First(&split, split.ID)
+	assert.True(t, split.IsCompleted)
+	assert.Equal(t, opp.ID, *split.WinnerID)
+
+	models.DB.Model(&models.TournamentSettings{}).Where("tournament_id = ?", tournament.ID).Update("panel_tie_break", TieBreakScores)
+	w = sendJSON(router, "GET", "/api/matches/"+strconv.Itoa(int(split.ID))+"/ballots", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data     []models.BallotSet `json:"data"`
+		Decision panelDecision      `json:"decision"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "gov", response.Decision.Winner)
+	assert.Equal(t, TieBreakScores, response.Decision.TieBroken)
+	assert.Equal(t, 1, response.Decision.GovVotes)
+	assert.Equal(t, 1, response.Decision.OppVotes)
+
+	// Wing 1 diganti wing 2: ballot wing 1 tidak dihitung lagi, match menunggu ballot wing 2
+	w = sendJSON(router, "PUT", "/api/matches/"+strconv.Itoa(int(split.ID))+"/panel",
+		fmt.Sprintf(`{"chief_adj_id":%d,"wing_adj_ids":[%d],"version":%d}`, chair.ID, wing2.ID, matchVersion(split.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&split, split.ID)
+	assert.False(t, split.IsCompleted)
+	assert.Nil(t, split.WinnerID)
+	w = sendJSON(router, "GET", "/api/matches/"+strconv.Itoa(int(split.ID))+"/ballots", "")
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, 1, response.Decision.Received)
+	assert.Equal(t, 0, response.Decision.GovVotes)
+	assert.Equal(t, 1, response.Decision.OppVotes)
+
+	w = submit(split.ID, wing2.ID, split.Version, "opp", [4]float64{74, 74, 76, 76})
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&split, split.ID)
+	assert.True(t, split.IsCompleted)
+	assert.Equal(t, opp.ID, *split.WinnerID)
+}
+
+func TestBallotConfirmation(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Confirm Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, RequireBallotConfirmation: true, SpeakersPerSide: 1})
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	pm := models.Speaker{Name: "PM", TeamID: gov.ID}
+	lo := models.Speaker{Name: "LO", TeamID: opp.ID}
+	models.DB.Create(&pm)
+	models.DB.Create(&lo)
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+
+	scores := func(pmScore, loScore float64) []ballotScore {
+		return []ballotScore{
+			{SpeakerID: pm.ID, Score: pmScore, Position: "PM", TeamRole: "gov"},
+			{SpeakerID: lo.ID, Score: loScore, Position: "LO", TeamRole: "opp"},
+		}
+	}
+	ballot := func(pmScore, loScore float64, extra ...string) string {
+		return ballotBody(match.ID, chair.ID, matchVersion(match.ID), "gov", scores(pmScore, loScore), extra...)
+	}
+	// Body konfirmasi: entri ulang kertas ballot oleh tabulator kedua
+	reentry := func(pmScore, loScore float64, version string) string {
+		return fmt.Sprintf(`{"winner":"gov"%s,"scores":%s}`, version, ballotScores(scores(pmScore, loScore)...))
+	}
+	var submitted struct {
+		BallotSetID   uint                `json:"ballot_set_id"`
+		Status        string              `json:"status"`
+		Discrepancies []ballotDiscrepancy `json:"discrepancies"`
+	}
+
+	// Draft tidak dihitung dan diganti saat juri menyimpan lagi
+	w := sendJSON(router, "POST", "/api/submit-ballot", ballot(75, 74, `"draft":true`), bearerToken(1))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &submitted)
+	assert.Equal(t, BallotDraft, submitted.Status)
+
+	// Entri pertama menunggu konfirmasi, hasil match belum berubah
+	w = sendJSON(router, "POST", "/api/submit-ballot", ballot(76, 74), bearerToken(1))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &submitted)
+	assert.Equal(t, BallotSubmitted, submitted.Status)
+	assert.Empty(t, submitted.Discrepancies)
+	first := submitted.BallotSetID
+	var drafts int64
+	models.DB.Model(&models.BallotSet{}).Where("status = ?", BallotDraft).Count(&drafts)
+	assert.Equal(t, int64(0), drafts)
+	models.DB.First(&match, match.ID)
+	assert.False(t, match.IsCompleted)
+
+	// Entri kedua kertas yang sama: perbedaan skor PM terlihat per isian
+	w = sendJSON(router, "POST", "/api/submit-ballot", ballot(77, 74), bearerToken(2))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &submitted)
+	second := submitted.BallotSetID
+	assert.Len(t, submitted.Discrepancies, 1)
+	assert.Equal(t, "score", submitted.Discrepancies[0].Field)
+	assert.Equal(t, "PM", submitted.Discrepancies[0].Position)
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/ballot-sets/%d/discrepancies?other_id=%d", first, second), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	confirmURL := fmt.Sprintf("/api/ballot-sets/%d/confirm", first)
+	w = sendJSON(router, "POST", confirmURL, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = sendJSON(router, "POST", confirmURL, "", bearerToken(1))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Entri ulang yang berbeda menolak konfirmasi
+	w = sendJSON(router, "POST", confirmURL, reentry(76, 75, ""), bearerToken(3))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "discrepancies")
+
+	w = sendJSON(router, "POST", confirmURL, reentry(76, 74, `,"version":1`), bearerToken(3))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Hanya ballot confirmed yang masuk klasemen; entri lain dibuang
+	models.DB.First(&match, match.ID)
+	assert.True(t, match.IsCompleted)
+	assert.Equal(t, gov.ID, *match.WinnerID)
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 76.0, pm.TotalScore)
+	var other models.BallotSet
+	models.DB.First(&other, second)
+	assert.Equal(t, BallotDiscarded, other.Status)
+
+	w = sendJSON(router, "POST", fmt.Sprintf("/api/ballot-sets/%d/confirm", second), "", bearerToken(3))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Membuang ballot yang sudah dikonfirmasi membatalkan hasil match
+	w = sendJSON(router, "POST", fmt.Sprintf("/api/ballot-sets/%d/discard", first), `{"version":2}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.False(t, match.IsCompleted)
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 0.0, pm.TotalScore)
+
+	// Penginput tidak diketahui (tanpa login): konfirmasi wajib dengan entri ulang
+	w = sendJSON(router, "POST", "/api/submit-ballot", ballot(78, 74))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &submitted)
+	anonymousURL := fmt.Sprintf("/api/ballot-sets/%d/confirm", submitted.BallotSetID)
+	assert.Equal(t, http.StatusBadRequest, sendJSON(router, "POST", anonymousURL, `{"version":1}`, bearerToken(1)).Code)
+	w = sendJSON(router, "POST", anonymousURL, reentry(78, 74, `,"version":1`), bearerToken(1))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBallotVersions(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Version Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, SpeakersPerSide: 1})
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	pm := models.Speaker{Name: "PM", TeamID: gov.ID}
+	lo := models.Speaker{Name: "LO", TeamID: opp.ID}
+	models.DB.Create(&pm)
+	models.DB.Create(&lo)
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+
+	submit := func(winner string, pmScore, loScore float64) {
+		body := ballotBody(match.ID, chair.ID, matchVersion(match.ID), winner, []ballotScore{
+			{SpeakerID: pm.ID, Score: pmScore, Position: "PM", TeamRole: "gov"},
+			{SpeakerID: lo.ID, Score: loScore, Position: "LO", TeamRole: "opp"},
+		})
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", "/api/submit-ballot", body).Code)
+	}
+	versionsURL := fmt.Sprintf("/api/matches/%d/ballot-versions", match.ID)
+
+	submit("gov", 76, 74)
+	submit("opp", 73, 77)
+
+	// Versi lama tetap tersimpan, versi terbaru yang berlaku
+	w := sendJSON(router, "GET", versionsURL, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var listing struct {
+		Data            []models.BallotSet `json:"data"`
+		CurrentRevision int                `json:"current_revision"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listing)
+	assert.Len(t, listing.Data, 2)
+	assert.Equal(t, 2, listing.CurrentRevision)
+	assert.Equal(t, BallotDiscarded, listing.Data[0].Status)
+	assert.Len(t, listing.Data[0].Ballots, 2)
+	models.DB.First(&match, match.ID)
+	assert.Equal(t, opp.ID, *match.WinnerID)
+
+	w = sendJSON(router, "GET", versionsURL+"/diff?from=1&to=2", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var diff struct {
+		Data []ballotDiscrepancy `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &diff)
+	assert.Len(t, diff.Data, 3) // pemenang + 2 skor
+	assert.Equal(t, http.StatusNotFound, sendJSON(router, "GET", versionsURL+"/diff?from=1&to=9", "").Code)
+
+	// Rollback ke versi 1 mengembalikan hasil & klasemen
+	w = sendJSON(router, "POST", versionsURL+"/1/rollback", fmt.Sprintf(`{"match_version":%d}`, matchVersion(match.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.Equal(t, 1, match.CurrentBallotRevision)
+	assert.Equal(t, gov.ID, *match.WinnerID)
+	models.DB.First(&gov, gov.ID)
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 1, gov.TotalVP)
+	assert.Equal(t, 76.0, pm.TotalScore)
+	var second models.BallotSet
+	models.DB.Where("match_id = ? AND revision = ?", match.ID, 2).First(&second)
+	assert.Equal(t, BallotDiscarded, second.Status)
+
+	assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "POST", versionsURL+"/1/rollback", "").Code)
+	assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", versionsURL+"/1/rollback", fmt.Sprintf(`{"match_version":%d}`, matchVersion(match.ID))).Code)
+
+	// Submit baru tetap menambah versi
+	submit("gov", 78, 74)
+	models.DB.First(&match, match.ID)
+	assert.Equal(t, 3, match.CurrentBallotRevision)
+	// Nomor versi unik per match; draft (0) boleh lebih dari satu
+	assert.Error(t, models.DB.Create(&models.BallotSet{MatchID: match.ID, AdjudicatorID: chair.ID, Revision: 3}).Error)
+	assert.NoError(t, models.DB.Create(&models.BallotSet{MatchID: match.ID, AdjudicatorID: chair.ID, Status: BallotDraft}).Error)
+	assert.NoError(t, models.DB.Create(&models.BallotSet{MatchID: match.ID, AdjudicatorID: chair.ID, Status: BallotDraft}).Error)
+}
+
+func TestBallotValidation(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Validation Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	var speakers []models.Speaker
+	for i, name := range []string{"PM", "DPM", "GW", "LO", "DLO", "OW"} {
+		speaker := models.Speaker{Name: name, TeamID: gov.ID}
+		if i >= 3 {
+			speaker.TeamID = opp.ID
+		}
+		models.DB.Create(&speaker)
+		speakers = append(speakers, speaker)
+	}
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+
+	submit := func(winner string, scores []ballotScore, extra ...string) *httptest.ResponseRecorder {
+		return sendJSON(router, "POST", "/api/submit-ballot", ballotBody(match.ID, chair.ID, matchVersion(match.ID), winner, scores, extra...))
+	}
+
+	// Semua pelanggaran dikembalikan sekaligus: rentang, kelipatan, jumlah speaker, low-point win
+	w := submit("opp", apScores(speakers, 90, 75.5, 75, 74, 74))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Violations []ballotViolation `json:"violations"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	fields := []string{}
+	for _, violation := range response.Violations {
+		fields = append(fields, violation.Field)
+	}
+	assert.ElementsMatch(t, []string{"score", "step", "speakers", "winner"}, fields)
+	var sets int64
+	models.DB.Model(&models.BallotSet{}).Count(&sets)
+	assert.Equal(t, int64(0), sets)
+
+	// Seri ditolak, reply di luar rentang ditolak
+	w = submit("gov", append(apScores(speakers, 75, 75, 75, 75, 75, 75),
+		ballotScore{SpeakerID: speakers[0].ID, Score: 45, Position: "PM", TeamRole: "gov", IsReply: true},
+		ballotScore{SpeakerID: speakers[3].ID, Score: 45, Position: "LO", TeamRole: "opp", IsReply: true}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response.Violations = nil
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Violations, 3)
+
+	// Draft belum divalidasi
+	w = submit("gov", apScores(speakers, 90), `"draft":true`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Setengah poin & low-point win diizinkan lewat settings
+	w = sendJSON(router, "PUT", "/api/tournaments/"+strconv.Itoa(int(tournament.ID))+"/settings", `{"score_step":0.5,"allow_low_point_wins":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusBadRequest, sendJSON(router, "PUT", "/api/tournaments/"+strconv.Itoa(int(tournament.ID))+"/settings", `{"score_step":0.25}`).Code)
+	w = submit("opp", apScores(speakers, 76, 75.5, 75, 74, 74, 75))
+	assert.Equal(t, http.StatusOK, w.Code)
+	models.DB.First(&match, match.ID)
+	assert.Equal(t, opp.ID, *match.WinnerID)
+	var dpm models.Speaker
+	models.DB.First(&dpm, speakers[1].ID)
+	assert.Equal(t, 75.5, dpm.TotalScore)
+}
+
+func TestReplySpeeches(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Reply Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	var speakers []models.Speaker
+	for i, name := range []string{"PM", "DPM", "GW", "LO", "DLO", "OW"} {
+		speaker := models.Speaker{Name: name, TeamID: gov.ID}
+		if i >= 3 {
+			speaker.TeamID = opp.ID
+		}
+		models.DB.Create(&speaker)
+		speakers = append(speakers, speaker)
+	}
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+
+	submit := func(govReply, oppReply models.Speaker) *httptest.ResponseRecorder {
+		body := ballotBody(match.ID, chair.ID, matchVersion(match.ID), "gov", apScores(speakers, 76, 75, 74, 75, 74, 73),
+			fmt.Sprintf(`"gov_reply":{"speaker_id":%d,"score":38}`, govReply.ID),
+			fmt.Sprintf(`"opp_reply":{"speaker_id":%d,"score":37}`, oppReply.ID))
+		return sendJSON(router, "POST", "/api/submit-ballot", body)
+	}
+
+	// Reply hanya boleh dari speaker pertama atau kedua
+	w := submit(speakers[0], speakers[5])
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "speaker pertama atau kedua")
+
+	w = submit(speakers[0], speakers[4])
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var replies []models.Ballot
+	models.DB.Where("is_reply = ?", true).Order("id asc").Find(&replies)
+	assert.Len(t, replies, 2)
+	assert.Equal(t, speakers[0].ID, replies[0].SpeakerID)
+	assert.Equal(t, "Reply", replies[0].Position)
+
+	// Reply masuk total tim, tidak masuk tab speaker
+	models.DB.First(&gov, gov.ID)
+	models.DB.First(&opp, opp.ID)
+	assert.Equal(t, 263.0, gov.TotalSpeaker) // 76 + 75 + 74 + 38
+	assert.Equal(t, 259.0, opp.TotalSpeaker) // 75 + 74 + 73 + 37
+	var pm models.Speaker
+	models.DB.First(&pm, speakers[0].ID)
+	assert.Equal(t, 76.0, pm.TotalScore)
+	assert.Equal(t, 38.0, pm.ReplyTotal)
+
+	w = sendJSON(router, "GET", "/api/standings/replies?tournament_id="+strconv.Itoa(int(tournament.ID)), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tab struct {
+		Data []replyStanding `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tab)
+	assert.Len(t, tab.Data, 2)
+	assert.Equal(t, "PM", tab.Data[0].Name)
+	assert.Equal(t, 38.0, tab.Data[0].ReplyAverage)
+	assert.Equal(t, "DLO", tab.Data[1].Name)
+	assert.Equal(t, 2, tab.Data[1].Rank)
+
+	// Kelayakan reply dari posisi, bukan urutan isian: GW di urutan pertama tetap tidak boleh reply
+	rules := scoreRulesFor("AP", models.TournamentSettings{})
+	reordered := func(govReplyID uint) BallotInput {
+		input := BallotInput{Winner: "gov"}
+		positions := []string{"GW", "PM", "DPM", "OW", "LO", "DLO"}
+		order := []int{2, 0, 1, 5, 3, 4}
+		for i, idx := range order {
+			role := "gov"
+			if i >= 3 {
+				role = "opp"
+			}
+			input.Scores = append(input.Scores, models.Ballot{SpeakerID: speakers[idx].ID, Score: 75, Position: positions[i], TeamRole: role})
+		}
+		input.Scores = append(input.Scores,
+			models.Ballot{SpeakerID: govReplyID, Score: 38, TeamRole: "gov", IsReply: true},
+			models.Ballot{SpeakerID: speakers[3].ID, Score: 37, TeamRole: "opp", IsReply: true})
+		return input
+	}
+	assert.Len(t, validateBallot(rules, reordered(speakers[2].ID)), 1)
+	assert.Empty(t, validateBallot(rules, reordered(speakers[1].ID)))
+}
+
+func TestIronPersonAndSubstitutes(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Iron Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	pm := models.Speaker{Name: "PM", TeamID: gov.ID}
+	dpm := models.Speaker{Name: "DPM", TeamID: gov.ID}
+	lo := models.Speaker{Name: "LO", TeamID: opp.ID}
+	dlo := models.Speaker{Name: "DLO", TeamID: opp.ID}
+	for _, speaker := range []*models.Speaker{&pm, &dpm, &lo, &dlo} {
+		models.DB.Create(speaker)
+	}
+	chair := models.Adjudicator{Name: "Chair", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+
+	ballot := func(govSecond, govThird, oppThird string) string {
+		return ballotBody(match.ID, chair.ID, matchVersion(match.ID), "gov", []ballotScore{
+			{Name: "PM", Score: 76, Position: "PM", TeamRole: "gov"},
+			{Name: govSecond, Score: 75, Position: "DPM", TeamRole: "gov"},
+			{Name: govThird, Score: 77, Position: "GW", TeamRole: "gov"},
+			{Name: "LO", Score: 74, Position: "LO", TeamRole: "opp"},
+			{Name: "DLO", Score: 74, Position: "DLO", TeamRole: "opp"},
+			{Name: oppThird, Score: 73, Position: "OW", TeamRole: "opp"},
+		})
+	}
+
+	// Substitute belum terdaftar ditolak
+	w := sendJSON(router, "POST", "/api/submit-ballot", ballot("DPM", "PM", "Reserve"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Reserve")
+
+	w = sendJSON(router, "POST", fmt.Sprintf("/api/teams/%d/speakers", opp.ID), `{"name":"Reserve","is_substitute":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// PM mengisi posisi PM & GW: iron-person
+	w = sendJSON(router, "POST", "/api/submit-ballot", ballot("DPM", "PM", "Reserve"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var iron []models.Ballot
+	models.DB.Where("is_iron_person = ?", true).Find(&iron)
+	assert.Len(t, iron, 2)
+
+	// Total tim menghitung kedua pidato; tab speaker sesuai settings
+	models.DB.First(&gov, gov.ID)
+	assert.Equal(t, 228.0, gov.TotalSpeaker)
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 76.0, pm.TotalScore)
+
+	settingsURL := fmt.Sprintf("/api/tournaments/%d/settings", tournament.ID)
+	recalculateURL := fmt.Sprintf("/api/standings/recalculate?tournament_id=%d", tournament.ID)
+	assert.Equal(t, http.StatusBadRequest, sendJSON(router, "PUT", settingsURL, `{"iron_person_tab":"average"}`).Code)
+	sendJSON(router, "PUT", settingsURL, `{"iron_person_tab":"highest"}`)
+	sendJSON(router, "POST", recalculateURL, "")
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 77.0, pm.TotalScore)
+	sendJSON(router, "PUT", settingsURL, `{"iron_person_tab":"both"}`)
+	sendJSON(router, "POST", recalculateURL, "")
+	models.DB.First(&pm, pm.ID)
+	assert.Equal(t, 153.0, pm.TotalScore)
+
+	// Satu speaker maksimal dua posisi
+	w = sendJSON(router, "POST", "/api/submit-ballot", ballot("PM", "PM", "Reserve"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBallotProgress(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Progress Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, RequireBallotConfirmation: true, SpeakersPerSide: 1})
+	released := time.Now().Add(-10 * time.Minute)
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID, DrawStatus: DrawStatusReleased, DrawReleasedAt: &released}
+	models.DB.Create(&round)
+
+	// Satu match per skenario, masing-masing dengan tim & juri sendiri
+	newMatch := func(name string, panel int) (models.Match, []models.Adjudicator) {
+		gov := models.Team{Name: name + " Gov", TournamentID: tournament.ID}
+		opp := models.Team{Name: name + " Opp", TournamentID: tournament.ID}
+		models.DB.Create(&gov)
+		models.DB.Create(&opp)
+		models.DB.Create(&models.Speaker{Name: "PM", TeamID: gov.ID})
+		models.DB.Create(&models.Speaker{Name: "LO", TeamID: opp.ID})
+		match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID}
+		models.DB.Create(&match)
+		var adjs []models.Adjudicator
+		for i := 0; i < panel; i++ {
+			adj := models.Adjudicator{Name: fmt.Sprintf("%s Judge %d", name, i+1), TournamentID: tournament.ID}
+			models.DB.Create(&adj)
+			role := PanelRolePanellist
+			if i == 0 {
+				role = PanelRoleChair
+			}
+			models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: adj.ID, Role: role})
+			adjs = append(adjs, adj)
+		}
+		return match, adjs
+	}
+	submit := func(match models.Match, adj models.Adjudicator, pmScore float64, userID uint) uint {
+		body := ballotBody(match.ID, adj.ID, matchVersion(match.ID), "gov", []ballotScore{
+			{Name: "PM", Score: pmScore, Position: "PM", TeamRole: "gov"},
+			{Name: "LO", Score: 74, Position: "LO", TeamRole: "opp"},
+		})
+		w := sendJSON(router, "POST", "/api/submit-ballot", body, bearerToken(userID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			BallotSetID uint `json:"ballot_set_id"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.BallotSetID
+	}
+	confirm := func(setID uint) {
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", fmt.Sprintf("/api/ballot-sets/%d/confirm", setID), `{"version":1}`, bearerToken(99)).Code)
+	}
+
+	none, _ := newMatch("None", 2)
+	partial, partialPanel := newMatch("Partial", 3)
+	confirm(submit(partial, partialPanel[0], 76, 1))
+	submitted, submittedPanel := newMatch("Submitted", 1)
+	submit(submitted, submittedPanel[0], 76, 1)
+	disputed, disputedPanel := newMatch("Disputed", 1)
+	submit(disputed, disputedPanel[0], 76, 1)
+	submit(disputed, disputedPanel[0], 77, 2)
+	confirmed, confirmedPanel := newMatch("Confirmed", 1)
+	confirm(submit(confirmed, confirmedPanel[0], 76, 1))
+	bye := models.Match{RoundID: round.ID, GovTeamID: none.GovTeamID, IsBye: true}
+	models.DB.Create(&bye)
+
+	w := sendJSON(router, "GET", fmt.Sprintf("/api/rounds/%d/ballot-progress", round.ID), "", bearerToken(1))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []matchProgress `json:"data"`
+		Round struct {
+			SecondsSinceRelease int64          `json:"seconds_since_release"`
+			TotalMatches        int            `json:"total_matches"`
+			Outstanding         int            `json:"outstanding"`
+			Counts              map[string]int `json:"counts"`
+		} `json:"round"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Data, 6)
+	byMatch := make(map[uint]matchProgress)
+	for _, entry := range response.Data {
+		byMatch[entry.MatchID] = entry
+	}
+	assert.Equal(t, ProgressNone, byMatch[none.ID].Status)
+	assert.Len(t, byMatch[none.ID].Missing, 2)
+	assert.Equal(t, ProgressPartial, byMatch[partial.ID].Status)
+	assert.Len(t, byMatch[partial.ID].Missing, 2)
+	assert.Equal(t, PanelRolePanellist, byMatch[partial.ID].Missing[0].Role)
+	assert.Equal(t, ProgressSubmitted, byMatch[submitted.ID].Status)
+	assert.Empty(t, byMatch[submitted.ID].Missing)
+	assert.Equal(t, ProgressDisputed, byMatch[disputed.ID].Status)
+	assert.Len(t, byMatch[disputed.ID].Disputes, 1)
+	assert.Equal(t, ProgressConfirmed, byMatch[confirmed.ID].Status)
+	assert.Equal(t, ProgressNotRequired, byMatch[bye.ID].Status)
+
+	assert.GreaterOrEqual(t, response.Round.SecondsSinceRelease, int64(600))
+	assert.Equal(t, 6, response.Round.TotalMatches)
+	assert.Equal(t, 4, response.Round.Outstanding)
+	assert.Equal(t, 1, response.Round.Counts[ProgressDisputed])
+}
+
+func TestIdempotentBallots(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Offline Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, SpeakersPerSide: 1})
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	models.DB.Create(&models.Speaker{Name: "PM", TeamID: gov.ID})
+	models.DB.Create(&models.Speaker{Name: "LO", TeamID: opp.ID})
+	adj := models.Adjudicator{Name: "Judge", TournamentID: tournament.ID}
+	models.DB.Create(&adj)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &adj.ID}
+	models.DB.Create(&match)
+
+	scores := func(pmScore float64) []ballotScore {
+		return []ballotScore{
+			{Name: "PM", Score: pmScore, Position: "PM", TeamRole: "gov"},
+			{Name: "LO", Score: 74, Position: "LO", TeamRole: "opp"},
+		}
+	}
+	ballot := func(submissionID string, pmScore float64, extra ...string) string {
+		extra = append([]string{fmt.Sprintf(`"submission_id":%q`, submissionID)}, extra...)
+		return ballotBody(match.ID, adj.ID, matchVersion(match.ID), "", scores(pmScore), extra...)
+	}
+	countSets := func() int64 {
+		var count int64
+		models.DB.Model(&models.BallotSet{}).Where("match_id = ?", match.ID).Count(&count)
+		return count
+	}
+
+	t.Run("Replay Returns Original Result", func(t *testing.T) {
+		w := sendJSON(router, "POST", "/api/submit-ballot", ballot("device-1", 76))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var first map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &first)
+
+		w = sendJSON(router, "POST", "/api/submit-ballot", ballot("device-1", 76))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var replay map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &replay)
+		assert.Equal(t, true, replay["replayed"])
+		assert.Equal(t, first["ballot_set_id"], replay["ballot_set_id"])
+		assert.Equal(t, int64(1), countSets())
+	})
+
+	t.Run("Submission ID Reused For Other Ballot", func(t *testing.T) {
+		other := ballotBody(match.ID, 999, matchVersion(match.ID), "", scores(76), `"submission_id":"device-1"`)
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "POST", "/api/submit-ballot", other).Code)
+	})
+
+	t.Run("Failed Submission Is Not Recorded", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, sendJSON(router, "POST", "/api/submit-ballot", ballot("device-2", 99)).Code)
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", "/api/submit-ballot", ballot("device-2", 77)).Code)
+		assert.Equal(t, int64(2), countSets())
+	})
+
+	t.Run("Batch Reports Per Item Results", func(t *testing.T) {
+		// Dicatat offline saat revisi match masih 0; sudah ada dua versi setelahnya
+		body := "{\"ballots\":[" + ballot("device-1", 76) + "," +
+			ballot("device-3", 78, `"base_revision":0`) + "," +
+			ballot("device-4", 99) + "," +
+			ballot("device-5", 78, `"base_revision":2`) + "]}"
+		w := sendJSON(router, "POST", "/api/ballots/batch", body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []struct {
+				Index      int                    `json:"index"`
+				Status     string                 `json:"status"`
+				HTTPStatus int                    `json:"http_status"`
+				Result     map[string]interface{} `json:"result"`
+			} `json:"data"`
+			Summary map[string]int `json:"summary"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Data, 4)
+		assert.Equal(t, "replayed", response.Data[0].Status)
+		assert.Equal(t, "conflict", response.Data[1].Status)
+		assert.Equal(t, float64(2), response.Data[1].Result["current_revision"])
+		current, _ := response.Data[1].Result["current"].(map[string]interface{})
+		assert.Equal(t, float64(match.ID), current["ID"])
+		assert.Equal(t, true, current["is_completed"])
+		assert.Equal(t, "error", response.Data[2].Status)
+		assert.Equal(t, http.StatusBadRequest, response.Data[2].HTTPStatus)
+		assert.Equal(t, "ok", response.Data[3].Status)
+		assert.Equal(t, map[string]int{"ok": 1, "replayed": 1, "conflict": 1, "error": 1}, response.Summary)
+		assert.Equal(t, int64(3), countSets())
+	})
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Lock Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	models.DB.Create(&models.TournamentSettings{TournamentID: tournament.ID, SpeakersPerSide: 1})
+	round := models.Round{Name: "Round 1", TournamentID: tournament.ID}
+	models.DB.Create(&round)
+	gov := models.Team{Name: "Gov", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Opp", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	models.DB.Create(&models.Speaker{Name: "PM", TeamID: gov.ID})
+	models.DB.Create(&models.Speaker{Name: "LO", TeamID: opp.ID})
+	adj := models.Adjudicator{Name: "Judge", TournamentID: tournament.ID}
+	models.DB.Create(&adj)
+	match := models.Match{RoundID: round.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &adj.ID}
+	models.DB.Create(&match)
+	assert.Equal(t, 1, match.Version)
+
+	currentVersion := func(w *httptest.ResponseRecorder) float64 {
+		var response struct {
+			Current map[string]interface{} `json:"current"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		version, _ := response.Current["version"].(float64)
+		return version
+	}
+	matchURL := fmt.Sprintf("/api/matches/%d/result", match.ID)
+
+	t.Run("Version Required", func(t *testing.T) {
+		w := sendJSON(router, "PUT", matchURL, fmt.Sprintf(`{"winner_id":%d,"is_completed":true}`, gov.ID))
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	})
+
+	t.Run("Stale Match Edit Rejected", func(t *testing.T) {
+		// Dua tabulator membuka match di versi 1; yang kedua menyimpan belakangan
+		first := sendJSON(router, "PUT", matchURL, fmt.Sprintf(`{"winner_id":%d,"is_completed":true,"version":1}`, gov.ID))
+		assert.Equal(t, http.StatusOK, first.Code)
+		second := sendJSON(router, "PUT", matchURL, fmt.Sprintf(`{"winner_id":%d,"is_completed":true,"version":1}`, opp.ID))
+		assert.Equal(t, http.StatusConflict, second.Code)
+		assert.Equal(t, float64(2), currentVersion(second))
+		models.DB.First(&match, match.ID)
+		assert.Equal(t, gov.ID, *match.WinnerID)
+	})
+
+	t.Run("Replacing Own Ballot Against Stale Match Version", func(t *testing.T) {
+		ballot := func(version int, winner string, govScore, oppScore float64) string {
+			return ballotBody(match.ID, adj.ID, version, winner, []ballotScore{
+				{Name: "PM", Score: govScore, Position: "PM", TeamRole: "gov"},
+				{Name: "LO", Score: oppScore, Position: "LO", TeamRole: "opp"},
+			})
+		}
+		// Ballot pertama juri tidak terikat versi match
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", "/api/submit-ballot", ballot(1, "opp", 74, 76)).Code)
+		models.DB.First(&match, match.ID)
+		assert.Equal(t, 3, match.Version)
+
+		// Mengganti ballot yang sudah dihitung wajib memakai versi terkini
+		assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "POST", "/api/submit-ballot", ballot(-1, "gov", 76, 74)).Code)
+		w := sendJSON(router, "POST", "/api/submit-ballot", ballot(2, "gov", 76, 74))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(3), currentVersion(w))
+
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", "/api/submit-ballot", ballot(3, "opp", 73, 77)).Code)
+		models.DB.First(&match, match.ID)
+		assert.Equal(t, 4, match.Version)
+		assert.Equal(t, opp.ID, *match.WinnerID)
+	})
+
+	t.Run("Stale Ballot Set And Round", func(t *testing.T) {
+		var set models.BallotSet
+		models.DB.Where("match_id = ? AND status = ?", match.ID, BallotConfirmed).First(&set)
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/ballot-sets/%d/discard", set.ID), `{"version":5}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(1), currentVersion(w))
+		assert.Equal(t, http.StatusOK, sendJSON(router, "POST", fmt.Sprintf("/api/ballot-sets/%d/discard", set.ID), `{"version":1}`).Code)
+
+		roundURL := fmt.Sprintf("/api/rounds/%d/status", round.ID)
+		assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", roundURL, `{"status":"completed","version":1}`).Code)
+		w = sendJSON(router, "PUT", roundURL, `{"status":"in_progress","version":1}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(2), currentVersion(w))
+		models.DB.First(&round, round.ID)
+		assert.Equal(t, "completed", round.Status)
+	})
+
+	t.Run("Publish And Importance Need Versions", func(t *testing.T) {
+		publishURL := fmt.Sprintf("/api/rounds/%d/publish-draw", round.ID)
+		assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "PUT", publishURL, `{"is_draw_published":true}`).Code)
+		// Publish memakai versi lama tidak menimpa perubahan status ronde
+		w := sendJSON(router, "PUT", publishURL, `{"is_draw_published":true,"version":1}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(2), currentVersion(w))
+		assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", publishURL, `{"is_draw_published":true,"version":2}`).Code)
+		motionURL := fmt.Sprintf("/api/rounds/%d/publish-motion", round.ID)
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "PUT", motionURL, `{"is_motion_published":true,"version":2}`).Code)
+		assert.Equal(t, http.StatusOK, sendJSON(router, "PUT", motionURL, `{"is_motion_published":true,"version":3}`).Code)
+		models.DB.First(&round, round.ID)
+		assert.True(t, round.IsDrawPublished)
+		assert.True(t, round.IsMotionPublished)
+		assert.Equal(t, "completed", round.Status)
+		assert.Equal(t, 4, round.Version)
+
+		importanceURL := fmt.Sprintf("/api/matches/%d/importance", match.ID)
+		assert.Equal(t, http.StatusPreconditionRequired, sendJSON(router, "PUT", importanceURL, `{"importance":2}`).Code)
+		assert.Equal(t, http.StatusConflict, sendJSON(router, "PUT", importanceURL, `{"importance":2,"version":1}`).Code)
+		w = sendJSON(router, "PUT", importanceURL, fmt.Sprintf(`{"importance":2,"version":%d}`, matchVersion(match.ID)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		models.DB.First(&match, match.ID)
+		assert.Equal(t, 2, match.Importance)
+	})
+}
+
+func TestPrintSheets(t *testing.T) {
+	setupControllerTestDB()
+	router := setupControllerTestRouter()
+
+	tournament := models.Tournament{Name: "Paper Cup", Format: "asian"}
+	models.DB.Create(&tournament)
+	round := models.Round{Name: "Round 2", TournamentID: tournament.ID, Motion: "THW ban homework (for now)", DrawStatus: DrawStatusDraft}
+	models.DB.Create(&round)
+	room := models.Room{Name: "A1", TournamentID: tournament.ID}
+	models.DB.Create(&room)
+	gov := models.Team{Name: "Alpha", TournamentID: tournament.ID}
+	opp := models.Team{Name: "Beta", TournamentID: tournament.ID}
+	models.DB.Create(&gov)
+	models.DB.Create(&opp)
+	for _, name := range []string{"Ana", "Budi", "Citra"} {
+		models.DB.Create(&models.Speaker{Name: name, TeamID: gov.ID})
+	}
+	models.DB.Create(&models.Speaker{Name: "Dewi", TeamID: opp.ID})
+	models.DB.Create(&models.Speaker{Name: "Eko", TeamID: opp.ID, IsSubstitute: true})
+	chair := models.Adjudicator{Name: "Chair Judge", TournamentID: tournament.ID}
+	wing := models.Adjudicator{Name: "Wing Judge", TournamentID: tournament.ID}
+	models.DB.Create(&chair)
+	models.DB.Create(&wing)
+	match := models.Match{RoundID: round.ID, RoomID: &room.ID, GovTeamID: &gov.ID, OppTeamID: &opp.ID, AdjudicatorID: &chair.ID}
+	models.DB.Create(&match)
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: chair.ID, Role: PanelRoleChair})
+	models.DB.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: wing.ID, Role: PanelRolePanellist})
+	models.DB.Create(&models.Match{RoundID: round.ID, GovTeamID: &gov.ID, IsBye: true})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		return sendJSON(router, "GET", fmt.Sprintf("/api/rounds/%d/print-sheets%s", round.ID, query), "")
+	}
+
+	assert.Equal(t, http.StatusConflict, get("").Code)
+	models.DB.Model(&round).Update("draw_status", DrawStatusReleased)
+
+	w := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	pdf := w.Body.String()
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	// Dua ballot (chair + wing) dan dua formulir feedback; match bye dilewati
+	assert.Contains(t, pdf, "/Count 4")
+	for _, text := range []string{"Paper Cup - Round 2", "Room: A1", "THW ban homework \\(for now\\)",
+		"Chair Judge \\(chair\\)", "Wing Judge \\(panellist\\)", "Government: Alpha", "(Citra)", "Substitute: Eko",
+		"Skor speaker 68-82", "ADJUDICATOR FEEDBACK", "Beta \\(Opposition\\)"} {
+		assert.Contains(t, pdf, text)
+	}
+
+	// Offset di tabel xref harus menunjuk ke awal objek masing-masing
+	xref := pdf[strings.LastIndex(pdf, "\nxref\n")+1:]
+	entries := strings.Split(xref, "\n")[3:]
+	for i := 1; i <= 10; i++ {
+		offset, err := strconv.Atoi(entries[i-1][:10])
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d 0 obj", i)))
+	}
+
+	w = get("?sheets=feedback")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/Count 2")
+	assert.NotContains(t, w.Body.String(), "(BALLOT)")
+	assert.Equal(t, http.StatusBadRequest, get("?sheets=bogus").Code)
 }
 
 func TestErrorHandling(t *testing.T) {
@@ -0,0 +1,549 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// drawTeam: ringkasan tim yang dipakai saat membuat draw
+type drawTeam struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Institution string  `json:"institution"`
+	Points      int     `json:"points"`
+	Speaks      float64 `json:"speaks"`
+	IsSwing     bool    `json:"is_swing"`
+	GovCount    int     `json:"-"`
+	OppCount    int     `json:"-"`
+	HadBye      bool    `json:"-"`
+}
+
+// drawPairing: satu debat hasil pairing (AP: Gov vs Opp)
+type drawPairing struct {
+	Gov     drawTeam `json:"gov"`
+	Opp     drawTeam `json:"opp"`
+	Bracket int      `json:"bracket"` // VP tertinggi di bracket asal pairing
+}
+
+// Metode pairing & pull-up draw
+const (
+	PairingFold   = "fold"   // Teratas vs terbawah dalam bracket
+	PairingSlide  = "slide"  // Teratas vs teratas paruh bawah
+	PairingRandom = "random" // Acak dalam bracket
+	PullUpTop     = "top"    // Tim teratas bracket bawah yang naik
+	PullUpBottom  = "bottom" // Tim terbawah bracket bawah yang naik
+	PullUpRandom  = "random" // Tim acak dari bracket bawah
+)
+
+// drawOptions: pengaturan pembuatan draw (bisa dibandingkan lewat preview)
+type drawOptions struct {
+	PairingMethod string `json:"pairing_method"` // default "fold"
+	PullUpMethod  string `json:"pull_up_method"` // default "top"
+	Seed          int64  `json:"seed"`           // 0 = seed baru; dikembalikan agar hasil bisa diulang
+}
+
+// normalize mengisi default dan memvalidasi pilihan metode
+func (o *drawOptions) normalize() error {
+	if o.PairingMethod == "" {
+		o.PairingMethod = PairingFold
+	}
+	if o.PullUpMethod == "" {
+		o.PullUpMethod = PullUpTop
+	}
+	if o.PairingMethod != PairingFold && o.PairingMethod != PairingSlide && o.PairingMethod != PairingRandom {
+		return errors.New("pairing_method must be 'fold', 'slide' or 'random'")
+	}
+	if o.PullUpMethod != PullUpTop && o.PullUpMethod != PullUpBottom && o.PullUpMethod != PullUpRandom {
+		return errors.New("pull_up_method must be 'top', 'bottom' or 'random'")
+	}
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+	return nil
+}
+
+// drawBracket: ringkasan satu bracket VP
+type drawBracket struct {
+	Points   int    `json:"points"`
+	Teams    int    `json:"teams"`     // Termasuk tim hasil pull-up
+	PulledUp []uint `json:"pulled_up"` // ID tim yang naik dari bracket bawah
+}
+
+// drawProposal: hasil pairing sebelum disimpan
+type drawProposal struct {
+	Pairings []drawPairing `json:"pairings"`
+	Brackets []drawBracket `json:"brackets"`
+	Bye      *drawTeam     `json:"bye"`
+	Options  drawOptions   `json:"options"`
+}
+
+// pairKey menyimpan pasangan tim yang sudah pernah bertemu (urutan ID tidak penting)
+type pairKey [2]uint
+
+func newPairKey(a, b uint) pairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return pairKey{a, b}
+}
+
+// sortDrawTeams mengurutkan tim berdasarkan klasemen: VP, speaker score, lalu ID
+func sortDrawTeams(teams []drawTeam) {
+	sort.SliceStable(teams, func(i, j int) bool {
+		if teams[i].Points != teams[j].Points {
+			return teams[i].Points > teams[j].Points
+		}
+		if teams[i].Speaks != teams[j].Speaks {
+			return teams[i].Speaks > teams[j].Speaks
+		}
+		return teams[i].ID < teams[j].ID
+	})
+}
+
+// selectByeTeam memilih tim peringkat terbawah yang belum pernah mendapat bye
+func selectByeTeam(teams []drawTeam) int {
+	for i := len(teams) - 1; i >= 0; i-- {
+		if !teams[i].HadBye {
+			return i
+		}
+	}
+	return len(teams) - 1
+}
+
+// powerPair membuat pairing power-paired: tim dikelompokkan per VP (bracket), bracket
+// ganjil menarik satu tim dari bracket di bawahnya (pull-up), lalu tiap bracket dipasangkan
+// sesuai metode pairing. Rematch dihindari dengan menukar lawan di bracket yang sama.
+func powerPair(teams []drawTeam, met map[pairKey]bool, opts drawOptions) ([]drawPairing, []drawBracket) {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	pool := make([]drawTeam, len(teams))
+	copy(pool, teams)
+	sortDrawTeams(pool)
+
+	// Kelompokkan per VP
+	var brackets [][]drawTeam
+	for i, team := range pool {
+		if i == 0 || team.Points != pool[i-1].Points {
+			brackets = append(brackets, nil)
+		}
+		brackets[len(brackets)-1] = append(brackets[len(brackets)-1], team)
+	}
+
+	var pairings []drawPairing
+	var summary []drawBracket
+	for b := 0; b < len(brackets); b++ {
+		bracket := brackets[b]
+		info := drawBracket{PulledUp: []uint{}}
+		if len(bracket)%2 == 1 && b+1 < len(brackets) {
+			// Pull-up: satu tim bracket bawah naik ke bracket ini
+			below := brackets[b+1]
+			idx := 0
+			switch opts.PullUpMethod {
+			case PullUpBottom:
+				idx = len(below) - 1
+			case PullUpRandom:
+				idx = rng.Intn(len(below))
+			}
+			bracket = append(bracket, below[idx])
+			info.PulledUp = append(info.PulledUp, below[idx].ID)
+			brackets[b+1] = append(below[:idx:idx], below[idx+1:]...)
+		}
+		if len(bracket) == 0 {
+			continue
+		}
+		info.Points = bracket[0].Points
+		info.Teams = len(bracket)
+		summary = append(summary, info)
+
+		if opts.PairingMethod == PairingRandom {
+			rng.Shuffle(len(bracket), func(i, j int) { bracket[i], bracket[j] = bracket[j], bracket[i] })
+		}
+
+		var bracketPairs []drawPairing
+		n := len(bracket)
+		for i := 0; i < n/2; i++ {
+			var gov, opp drawTeam
+			switch opts.PairingMethod {
+			case PairingSlide:
+				gov, opp = bracket[i], bracket[i+n/2]
+			case PairingRandom:
+				gov, opp = bracket[2*i], bracket[2*i+1]
+			default:
+				gov, opp = bracket[i], bracket[n-1-i]
+			}
+			bracketPairs = append(bracketPairs, drawPairing{Gov: gov, Opp: opp, Bracket: info.Points})
+		}
+		avoidRematches(bracketPairs, met)
+		pairings = append(pairings, bracketPairs...)
+	}
+
+	for i := range pairings {
+		pairings[i].Gov, pairings[i].Opp = allocateSides(pairings[i].Gov, pairings[i].Opp)
+	}
+	return pairings, summary
+}
+
+// proposeDraw menyusun draw lengkap tanpa menyimpan apa pun. Jika jumlah tim ganjil, tim
+// terbawah yang belum pernah bye mendapat bye, atau swing team ikut dipasangkan.
+func proposeDraw(teams []drawTeam, met map[pairKey]bool, byeStrategy string, swing drawTeam, opts drawOptions) drawProposal {
+	pool := make([]drawTeam, len(teams))
+	copy(pool, teams)
+
+	proposal := drawProposal{Options: opts}
+	if len(pool)%2 == 1 {
+		sortDrawTeams(pool)
+		if byeStrategy == ByeStrategySwing {
+			pool = append(pool, swing)
+		} else {
+			idx := selectByeTeam(pool)
+			bye := pool[idx]
+			proposal.Bye = &bye
+			pool = append(pool[:idx], pool[idx+1:]...)
+		}
+	}
+	proposal.Pairings, proposal.Brackets = powerPair(pool, met, opts)
+	return proposal
+}
+
+// proposalMatches mengubah proposal menjadi baris Match (belum disimpan)
+func proposalMatches(roundID uint, proposal drawProposal) []models.Match {
+	var matches []models.Match
+	for _, pairing := range proposal.Pairings {
+		govID, oppID := pairing.Gov.ID, pairing.Opp.ID
+		matches = append(matches, models.Match{RoundID: roundID, GovTeamID: &govID, OppTeamID: &oppID})
+	}
+	if proposal.Bye != nil {
+		byeID := proposal.Bye.ID
+		matches = append(matches, models.Match{
+			RoundID:     roundID,
+			GovTeamID:   &byeID,
+			WinnerID:    &byeID,
+			IsBye:       true,
+			IsCompleted: true,
+		})
+	}
+	return matches
+}
+
+// avoidRematches menukar lawan antar pairing dalam satu bracket jika tim sudah pernah bertemu
+func avoidRematches(pairs []drawPairing, met map[pairKey]bool) {
+	for i := range pairs {
+		if !met[newPairKey(pairs[i].Gov.ID, pairs[i].Opp.ID)] {
+			continue
+		}
+		for j := range pairs {
+			if i == j {
+				continue
+			}
+			if !met[newPairKey(pairs[i].Gov.ID, pairs[j].Opp.ID)] &&
+				!met[newPairKey(pairs[j].Gov.ID, pairs[i].Opp.ID)] {
+				pairs[i].Opp, pairs[j].Opp = pairs[j].Opp, pairs[i].Opp
+				break
+			}
+		}
+	}
+}
+
+// allocateSides: tim yang lebih jarang jadi Gov mendapat posisi Gov
+func allocateSides(a, b drawTeam) (drawTeam, drawTeam) {
+	if a.GovCount-a.OppCount > b.GovCount-b.OppCount {
+		return b, a
+	}
+	return a, b
+}
+
+// loadDrawTeams mengambil tim turnamen yang tersedia di ronde ini beserta riwayat debat dari ronde lain
+func loadDrawTeams(db *gorm.DB, round models.Round) ([]drawTeam, map[pairKey]bool, error) {
+	var teams []models.Team
+	if err := db.Where("tournament_id = ? AND is_swing = ?", round.TournamentID, false).
+		Order("id asc").Find(&teams).Error; err != nil {
+		return nil, nil, err
+	}
+	available, err := roundAvailability(db, round, EntityTeam)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var history []models.Match
+	if err := db.Joins("JOIN rounds ON matches.round_id = rounds.id").
+		Where("rounds.tournament_id = ? AND matches.round_id <> ?", round.TournamentID, round.ID).
+		Find(&history).Error; err != nil {
+		return nil, nil, err
+	}
+
+	govCount := make(map[uint]int)
+	oppCount := make(map[uint]int)
+	hadBye := make(map[uint]bool)
+	met := make(map[pairKey]bool)
+	for _, match := range history {
+		if match.IsBye {
+			if match.GovTeamID != nil {
+				hadBye[*match.GovTeamID] = true
+			}
+			continue
+		}
+		if match.GovTeamID != nil {
+			govCount[*match.GovTeamID]++
+		}
+		if match.OppTeamID != nil {
+			oppCount[*match.OppTeamID]++
+		}
+		if match.GovTeamID != nil && match.OppTeamID != nil {
+			met[newPairKey(*match.GovTeamID, *match.OppTeamID)] = true
+		}
+	}
+
+	result := make([]drawTeam, 0, len(teams))
+	for _, team := range teams {
+		if !available[team.ID] {
+			continue
+		}
+		result = append(result, drawTeam{
+			ID:          team.ID,
+			Name:        team.Name,
+			Institution: team.Institution,
+			Points:      team.TotalVP,
+			Speaks:      team.TotalSpeaker,
+			GovCount:    govCount[team.ID],
+			OppCount:    oppCount[team.ID],
+			HadBye:      hadBye[team.ID],
+		})
+	}
+	return result, met, nil
+}
+
+// findOrCreateSwingTeam memakai swing team yang sudah ada di turnamen, atau membuat baru
+func findOrCreateSwingTeam(tx *gorm.DB, tournamentID uint) (models.Team, error) {
+	swing := models.Team{TournamentID: tournamentID, IsSwing: true}
+	err := tx.Where("tournament_id = ? AND is_swing = ?", tournamentID, true).
+		Attrs(models.Team{Name: "Swing Team", Institution: "Swing"}).
+		FirstOrCreate(&swing).Error
+	return swing, err
+}
+
+// drawContext: data yang dibutuhkan untuk menyusun draw satu ronde
+type drawContext struct {
+	Round      models.Round
+	Tournament models.Tournament
+	Settings   models.TournamentSettings
+	Teams      []drawTeam
+	Met        map[pairKey]bool
+	Options    drawOptions
+	Version    *int // Versi ronde dari body (wajib untuk generate-draw)
+}
+
+// loadDrawContext memuat ronde, turnamen, pengaturan & tim serta membaca drawOptions dari
+// body (opsional). Jika gagal, response error sudah dikirim dan hasil kedua bernilai false.
+func loadDrawContext(c *gin.Context) (drawContext, bool) {
+	var ctx drawContext
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		var body struct {
+			drawOptions
+			Version *int `json:"version"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return ctx, false
+		}
+		ctx.Options, ctx.Version = body.drawOptions, body.Version
+	}
+	if err := ctx.Options.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return ctx, false
+	}
+
+	if err := models.DB.First(&ctx.Round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return ctx, false
+	}
+	if err := models.DB.First(&ctx.Tournament, ctx.Round.TournamentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return ctx, false
+	}
+	if ctx.Tournament.Format == "british" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Automatic draw is only available for asian format"})
+		return ctx, false
+	}
+
+	var err error
+	if ctx.Settings, err = loadTournamentSettings(models.DB, ctx.Tournament.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ctx, false
+	}
+	if ctx.Teams, ctx.Met, err = loadDrawTeams(models.DB, ctx.Round); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ctx, false
+	}
+	if len(ctx.Teams) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least 2 teams are required to generate a draw"})
+		return ctx, false
+	}
+	return ctx, true
+}
+
+// POST /api/rounds/:id/generate-draw
+// Membuat draw power-paired untuk format Asian Parliamentary. Jika jumlah tim ganjil,
+// satu tim mendapat bye atau dipasangkan dengan swing team sesuai pengaturan turnamen.
+// Body: {"version": 1} (versi ronde, wajib) + opsional {"pairing_method": "fold", "pull_up_method": "top", "seed": 42}
+func GenerateDraw(c *gin.Context) {
+	ctx, ok := loadDrawContext(c)
+	if !ok {
+		return
+	}
+	if !requireVersion(c, ctx.Version) {
+		return
+	}
+	round := ctx.Round
+
+	var existing int64
+	models.DB.Model(&models.Match{}).Where("round_id = ?", round.ID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Round already has matches, delete them before generating a new draw"})
+		return
+	}
+
+	tx := models.DB.Begin()
+
+	var swing drawTeam
+	if len(ctx.Teams)%2 == 1 && ctx.Settings.ByeStrategy == ByeStrategySwing {
+		team, err := findOrCreateSwingTeam(tx, ctx.Tournament.ID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create swing team: " + err.Error()})
+			return
+		}
+		swing = drawTeam{ID: team.ID, Name: team.Name, Institution: team.Institution, IsSwing: true}
+	}
+
+	proposal := proposeDraw(ctx.Teams, ctx.Met, ctx.Settings.ByeStrategy, swing, ctx.Options)
+	matches := proposalMatches(round.ID, proposal)
+
+	if err := tx.Create(&matches).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save draw: " + err.Error()})
+		return
+	}
+
+	// Draw baru selalu mulai sebagai draft; riwayat edit draw lama tidak berlaku lagi
+	updated, err := updateVersioned(tx, &round, *ctx.Version, map[string]interface{}{"draw_status": DrawStatusDraft})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		tx.Rollback()
+		models.DB.First(&round, round.ID)
+		staleVersion(c, round)
+		return
+	}
+	tx.Where("round_id = ?", round.ID).Delete(&models.DrawEdit{})
+	// Bye baru dihitung sebagai kemenangan di klasemen setelah draw dirilis (UpdateDrawStatus)
+
+	tx.Commit()
+
+	models.DB.Preload("GovTeam").Preload("OppTeam").Where("round_id = ?", round.ID).Order("id asc").Find(&matches)
+	c.JSON(http.StatusOK, gin.H{"data": matches, "options": proposal.Options, "round_version": *ctx.Version + 1, "message": "Draw generated successfully"})
+}
+
+// drawDiffEntry: satu pairing di diff draw (OppTeamID 0 untuk bye)
+type drawDiffEntry struct {
+	GovTeamID uint `json:"gov_team_id"`
+	OppTeamID uint `json:"opp_team_id"`
+}
+
+// drawDiff: perbandingan proposal dengan draw yang tersimpan
+type drawDiff struct {
+	Added        []drawDiffEntry `json:"added"`         // Hanya ada di proposal
+	Removed      []drawDiffEntry `json:"removed"`       // Hanya ada di draw tersimpan
+	SidesFlipped []drawDiffEntry `json:"sides_flipped"` // Pairing sama, posisi Gov/Opp beda (posisi proposal)
+	Unchanged    int             `json:"unchanged"`
+}
+
+func diffEntry(match models.Match) drawDiffEntry {
+	var entry drawDiffEntry
+	if match.GovTeamID != nil {
+		entry.GovTeamID = *match.GovTeamID
+	}
+	if match.OppTeamID != nil {
+		entry.OppTeamID = *match.OppTeamID
+	}
+	return entry
+}
+
+// diffDraw membandingkan pairing (tanpa melihat ruangan/juri)
+func diffDraw(current, proposed []models.Match) drawDiff {
+	diff := drawDiff{Added: []drawDiffEntry{}, Removed: []drawDiffEntry{}, SidesFlipped: []drawDiffEntry{}}
+	currentByPair := make(map[pairKey]drawDiffEntry)
+	for _, match := range current {
+		entry := diffEntry(match)
+		currentByPair[newPairKey(entry.GovTeamID, entry.OppTeamID)] = entry
+	}
+	for _, match := range proposed {
+		entry := diffEntry(match)
+		key := newPairKey(entry.GovTeamID, entry.OppTeamID)
+		existing, ok := currentByPair[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, entry)
+		case existing.GovTeamID != entry.GovTeamID:
+			diff.SidesFlipped = append(diff.SidesFlipped, entry)
+		default:
+			diff.Unchanged++
+		}
+		delete(currentByPair, key)
+	}
+	for _, entry := range currentByPair {
+		diff.Removed = append(diff.Removed, entry)
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].GovTeamID < diff.Removed[j].GovTeamID })
+	return diff
+}
+
+// POST /api/rounds/:id/draw/preview
+// Dry-run pembuatan draw: mengembalikan pairing, bracket, hasil validasi, dan diff terhadap
+// draw yang tersimpan, tanpa menulis Match. Body sama dengan generate-draw.
+func PreviewDraw(c *gin.Context) {
+	ctx, ok := loadDrawContext(c)
+	if !ok {
+		return
+	}
+
+	// Swing team belum tentu ada; pakai yang sudah ada, atau placeholder (ID 0)
+	swing := drawTeam{Name: "Swing Team", Institution: "Swing", IsSwing: true}
+	var swingTeam models.Team
+	if err := models.DB.Where("tournament_id = ? AND is_swing = ?", ctx.Tournament.ID, true).First(&swingTeam).Error; err == nil {
+		swing.ID = swingTeam.ID
+	}
+
+	proposal := proposeDraw(ctx.Teams, ctx.Met, ctx.Settings.ByeStrategy, swing, ctx.Options)
+	proposed := proposalMatches(ctx.Round.ID, proposal)
+
+	var teams []models.Team
+	models.DB.Where("tournament_id = ?", ctx.Tournament.ID).Find(&teams)
+	if swing.ID == 0 && proposal.Bye == nil && len(ctx.Teams)%2 == 1 {
+		teams = append(teams, models.Team{Name: swing.Name, IsSwing: true})
+	}
+	available, err := roundAvailability(models.DB, ctx.Round, EntityTeam)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current []models.Match
+	models.DB.Where("round_id = ?", ctx.Round.ID).Order("id asc").Find(&current)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   proposal,
+		"issues": checkDraw(proposed, teams, available, ctx.Met),
+		"diff":   diffDraw(current, proposed),
+		"draft":  len(current) > 0,
+	})
+}
@@ -0,0 +1,587 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Status draw per ronde
+const (
+	DrawStatusDraft     = "draft"
+	DrawStatusConfirmed = "confirmed"
+	DrawStatusReleased  = "released"
+)
+
+// drawIssue: satu masalah hasil validasi draw
+type drawIssue struct {
+	Severity string `json:"severity"` // "error" (harus diperbaiki), "warning"
+	MatchID  uint   `json:"match_id,omitempty"`
+	TeamID   uint   `json:"team_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// matchSnapshot: posisi tim & ruangan sebuah match sebelum diedit
+type matchSnapshot struct {
+	ID        uint  `json:"id"`
+	GovTeamID *uint `json:"gov_team_id"`
+	OppTeamID *uint `json:"opp_team_id"`
+	RoomID    *uint `json:"room_id"`
+}
+
+// errDrawEdit: kesalahan input pada operasi edit draw (dikembalikan sebagai 400)
+type errDrawEdit struct{ msg string }
+
+func (e errDrawEdit) Error() string { return e.msg }
+
+func isDraftDraw(round models.Round) bool {
+	return round.DrawStatus == "" || round.DrawStatus == DrawStatusDraft
+}
+
+// drawHasResults: match (selain bye) yang sudah selesai atau sudah punya ballot membuat draw
+// tidak boleh diedit lagi, meski statusnya masih draft
+func drawHasResults(db *gorm.DB, roundID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Match{}).
+		Where("round_id = ? AND is_bye = ? AND (is_completed = ? OR id IN (?))", roundID, false, true,
+			db.Model(&models.BallotSet{}).Select("match_id")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// refuseDrawWithResults mengirim 409 (dan rollback tx) jika draw sudah punya hasil
+func refuseDrawWithResults(c *gin.Context, tx *gorm.DB, roundID uint) bool {
+	hasResults, err := drawHasResults(tx, roundID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if hasResults {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Draw already has results or ballots and can no longer be edited"})
+		return true
+	}
+	return false
+}
+
+func hasDrawErrors(issues []drawIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDraw memvalidasi daftar match satu ronde terhadap tim turnamen, ketersediaan tim di
+// ronde ini, dan riwayat pertemuan. Match tidak harus sudah tersimpan, sehingga bisa dipakai
+// untuk draw yang belum disimpan.
+func checkDraw(matches []models.Match, teams []models.Team, available map[uint]bool, met map[pairKey]bool) []drawIssue {
+	issues := []drawIssue{}
+	teamByID := make(map[uint]models.Team)
+	for _, team := range teams {
+		teamByID[team.ID] = team
+	}
+
+	seenTeam := make(map[uint]uint)
+	seenRoom := make(map[uint]uint)
+	for _, match := range matches {
+		if match.GovTeamID == nil || (!match.IsBye && match.OppTeamID == nil) {
+			issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, Message: "Match is missing a team"})
+		}
+		if match.GovTeamID != nil && match.OppTeamID != nil && *match.GovTeamID == *match.OppTeamID {
+			issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, TeamID: *match.GovTeamID, Message: "Team is drawn against itself"})
+		}
+
+		for _, teamID := range []*uint{match.GovTeamID, match.OppTeamID} {
+			if teamID == nil {
+				continue
+			}
+			if team, ok := teamByID[*teamID]; !ok {
+				issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, TeamID: *teamID, Message: "Team does not belong to this tournament"})
+			} else if !team.IsSwing && !available[*teamID] {
+				issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, TeamID: *teamID, Message: fmt.Sprintf("Team %s is not available this round", team.Name)})
+			}
+			if _, dup := seenTeam[*teamID]; dup {
+				issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, TeamID: *teamID, Message: fmt.Sprintf("Team %s appears in more than one match", teamByID[*teamID].Name)})
+			}
+			seenTeam[*teamID] = match.ID
+		}
+
+		if match.RoomID != nil && *match.RoomID != 0 {
+			if other, dup := seenRoom[*match.RoomID]; dup {
+				issues = append(issues, drawIssue{Severity: "error", MatchID: match.ID, Message: fmt.Sprintf("Room is also used by match %d", other)})
+			}
+			seenRoom[*match.RoomID] = match.ID
+		}
+
+		if match.GovTeamID != nil && match.OppTeamID != nil {
+			gov, opp := teamByID[*match.GovTeamID], teamByID[*match.OppTeamID]
+			if met[newPairKey(*match.GovTeamID, *match.OppTeamID)] {
+				issues = append(issues, drawIssue{Severity: "warning", MatchID: match.ID, Message: fmt.Sprintf("%s and %s have met before", gov.Name, opp.Name)})
+			}
+			if gov.Institution != "" && gov.Institution == opp.Institution {
+				issues = append(issues, drawIssue{Severity: "warning", MatchID: match.ID, Message: fmt.Sprintf("%s and %s are from the same institution", gov.Name, opp.Name)})
+			}
+		}
+	}
+
+	for _, team := range teams {
+		if _, ok := seenTeam[team.ID]; !ok && !team.IsSwing && available[team.ID] {
+			issues = append(issues, drawIssue{Severity: "error", TeamID: team.ID, Message: fmt.Sprintf("Team %s is not in the draw", team.Name)})
+		}
+	}
+	return issues
+}
+
+// validateDraw mengambil match ronde dari database lalu menjalankan checkDraw,
+// ditambah warning untuk panel juri yang berkonflik
+func validateDraw(db *gorm.DB, round models.Round) ([]drawIssue, error) {
+	var matches []models.Match
+	if err := db.Where("round_id = ?", round.ID).Order("id asc").Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	var teams []models.Team
+	if err := db.Where("tournament_id = ?", round.TournamentID).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	available, err := roundAvailability(db, round, EntityTeam)
+	if err != nil {
+		return nil, err
+	}
+	_, met, err := loadDrawTeams(db, round)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := panelConflictIssues(db, round, matches)
+	if err != nil {
+		return nil, err
+	}
+	return append(checkDraw(matches, teams, available, met), conflicts...), nil
+}
+
+// GET /api/rounds/:id/draw/validate
+func ValidateDraw(c *gin.Context) {
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	issues, err := validateDraw(models.DB, round)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": issues, "valid": !hasDrawErrors(issues)})
+}
+
+// PUT /api/rounds/:id/draw-status
+// Transisi: draft <-> confirmed <-> released. Draw dengan error validasi tidak bisa dikonfirmasi.
+func UpdateDrawStatus(c *gin.Context) {
+	var input struct {
+		DrawStatus string `json:"draw_status"`
+		Version    *int   `json:"version"` // Versi ronde yang diedit
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	if round.Version != *input.Version {
+		staleVersion(c, round)
+		return
+	}
+
+	allowed := map[string][]string{
+		DrawStatusDraft:     {DrawStatusConfirmed},
+		DrawStatusConfirmed: {DrawStatusDraft, DrawStatusReleased},
+		DrawStatusReleased:  {DrawStatusConfirmed},
+	}
+	current := round.DrawStatus
+	if current == "" {
+		current = DrawStatusDraft
+	}
+	valid := false
+	for _, next := range allowed[current] {
+		if next == input.DrawStatus {
+			valid = true
+		}
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot change draw status from '%s' to '%s'", current, input.DrawStatus)})
+		return
+	}
+
+	if input.DrawStatus == DrawStatusConfirmed && current == DrawStatusDraft {
+		issues, err := validateDraw(models.DB, round)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if hasDrawErrors(issues) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Draw has validation errors", "issues": issues})
+			return
+		}
+	}
+
+	values := map[string]interface{}{"draw_status": input.DrawStatus}
+	if input.DrawStatus == DrawStatusReleased {
+		values["draw_released_at"] = time.Now()
+	}
+	tx := models.DB.Begin()
+	updated, err := updateVersioned(tx, &round, *input.Version, values)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		tx.Rollback()
+		models.DB.First(&round, round.ID)
+		staleVersion(c, round)
+		return
+	}
+	// Bye ronde ini masuk klasemen saat draw dirilis dan keluar lagi jika rilis dibatalkan
+	if current == DrawStatusReleased || input.DrawStatus == DrawStatusReleased {
+		if _, err := recalculateStandings(tx, round.TournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+	models.DB.First(&round, round.ID)
+	c.JSON(http.StatusOK, gin.H{"data": round, "message": "Draw status updated"})
+}
+
+// applyDrawEdit menjalankan satu operasi edit pada draft draw secara atomik: posisi match
+// sebelum edit disimpan sebagai DrawEdit (untuk undo), lalu draw divalidasi ulang.
+// Issue tambahan dari operasi edit ikut dikembalikan bersama hasil validasi.
+// version = versi ronde yang diedit klien (wajib); versi ronde & match yang berubah dinaikkan.
+func applyDrawEdit(c *gin.Context, version *int, operation string, edit func(matches []models.Match) ([]drawIssue, error)) {
+	if !requireVersion(c, version) {
+		return
+	}
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	if !isDraftDraw(round) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Draw can only be edited while it is a draft"})
+		return
+	}
+
+	tx := models.DB.Begin()
+	if refuseDrawWithResults(c, tx, round.ID) {
+		return
+	}
+
+	var matches []models.Match
+	if err := tx.Where("round_id = ?", round.ID).Order("id asc").Find(&matches).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	snapshot := make([]matchSnapshot, 0, len(matches))
+	for _, match := range matches {
+		snapshot = append(snapshot, matchSnapshot{ID: match.ID, GovTeamID: match.GovTeamID, OppTeamID: match.OppTeamID, RoomID: match.RoomID})
+	}
+
+	editIssues, err := edit(matches)
+	if err != nil {
+		tx.Rollback()
+		var editErr errDrawEdit
+		if errors.As(err, &editErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := bumpRoundVersion(c, tx, &round, *version); err != nil {
+		return
+	}
+	if err := saveMatchPositions(tx, matches, snapshot); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	raw, _ := json.Marshal(snapshot)
+	if err := tx.Create(&models.DrawEdit{RoundID: round.ID, Operation: operation, Snapshot: string(raw)}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	issues, err := validateDraw(tx, round)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	models.DB.Where("round_id = ?", round.ID).Order("id asc").Find(&matches)
+	c.JSON(http.StatusOK, gin.H{"data": matches, "issues": append(editIssues, issues...), "round_version": round.Version})
+}
+
+// bumpRoundVersion menaikkan versi ronde jika masih sama dengan versi yang diedit klien.
+// Jika gagal, transaksi sudah di-rollback dan response error sudah dikirim.
+func bumpRoundVersion(c *gin.Context, tx *gorm.DB, round *models.Round, version int) error {
+	updated, err := updateVersioned(tx, round, version, map[string]interface{}{})
+	if err == nil && !updated {
+		err = errStaleVersion
+	}
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, errStaleVersion) {
+			models.DB.First(round, round.ID)
+			staleVersion(c, *round)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return err
+	}
+	round.Version = version + 1
+	return nil
+}
+
+// saveMatchPositions menyimpan posisi tim & ruangan (nil ditulis sebagai NULL) untuk match
+// yang berubah dibanding posisi sebelumnya, sekaligus menaikkan versi match tersebut
+func saveMatchPositions(tx *gorm.DB, matches []models.Match, before []matchSnapshot) error {
+	previous := make(map[uint]matchSnapshot, len(before))
+	for _, s := range before {
+		previous[s.ID] = s
+	}
+	for _, match := range matches {
+		if s, ok := previous[match.ID]; ok && sameUint(s.GovTeamID, match.GovTeamID) &&
+			sameUint(s.OppTeamID, match.OppTeamID) && sameUint(s.RoomID, match.RoomID) {
+			continue
+		}
+		if err := tx.Model(&models.Match{}).Where("id = ?", match.ID).Updates(map[string]interface{}{
+			"gov_team_id": match.GovTeamID,
+			"opp_team_id": match.OppTeamID,
+			"room_id":     match.RoomID,
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameUint: dua pointer ID bernilai sama (keduanya nil juga dianggap sama)
+func sameUint(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// findTeamSlot mencari match & posisi (gov/opp) sebuah tim di draw
+func findTeamSlot(matches []models.Match, teamID uint) (int, **uint) {
+	for i := range matches {
+		if matches[i].GovTeamID != nil && *matches[i].GovTeamID == teamID {
+			return i, &matches[i].GovTeamID
+		}
+		if matches[i].OppTeamID != nil && *matches[i].OppTeamID == teamID {
+			return i, &matches[i].OppTeamID
+		}
+	}
+	return -1, nil
+}
+
+// POST /api/rounds/:id/draw/swap-teams
+// Menukar dua tim antar match (masing-masing mengambil posisi tim lain)
+func SwapDrawTeams(c *gin.Context) {
+	var input struct {
+		TeamAID uint `json:"team_a_id"`
+		TeamBID uint `json:"team_b_id"`
+		Version *int `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyDrawEdit(c, input.Version, "swap_teams", func(matches []models.Match) ([]drawIssue, error) {
+		idxA, slotA := findTeamSlot(matches, input.TeamAID)
+		idxB, slotB := findTeamSlot(matches, input.TeamBID)
+		if slotA == nil || slotB == nil {
+			return nil, errDrawEdit{"Both teams must be in this round's draw"}
+		}
+		if idxA == idxB {
+			return nil, errDrawEdit{"Teams are in the same match, use flip-sides instead"}
+		}
+		if matches[idxA].IsBye || matches[idxB].IsBye {
+			return nil, errDrawEdit{"Teams with a bye cannot be swapped"}
+		}
+		*slotA, *slotB = *slotB, *slotA
+		return nil, nil
+	})
+}
+
+// POST /api/rounds/:id/draw/flip-sides
+// Menukar posisi Gov dan Opp dalam satu match
+func FlipDrawSides(c *gin.Context) {
+	var input struct {
+		MatchID uint `json:"match_id"`
+		Version *int `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyDrawEdit(c, input.Version, "flip_sides", func(matches []models.Match) ([]drawIssue, error) {
+		for i := range matches {
+			if matches[i].ID != input.MatchID {
+				continue
+			}
+			if matches[i].IsBye {
+				return nil, errDrawEdit{"A bye has no sides to flip"}
+			}
+			matches[i].GovTeamID, matches[i].OppTeamID = matches[i].OppTeamID, matches[i].GovTeamID
+			return nil, nil
+		}
+		return nil, errDrawEdit{"Match is not in this round"}
+	})
+}
+
+// POST /api/rounds/:id/draw/move-team
+// Memindahkan match sebuah tim ke ruangan lain. Jika ruangan itu sudah dipakai match lain
+// di ronde yang sama, kedua match bertukar ruangan.
+func MoveDrawTeam(c *gin.Context) {
+	var input struct {
+		TeamID  uint `json:"team_id"`
+		RoomID  uint `json:"room_id"`
+		Version *int `json:"version"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var room models.Room
+	if err := models.DB.First(&room, input.RoomID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	applyDrawEdit(c, input.Version, "move_team", func(matches []models.Match) ([]drawIssue, error) {
+		idx, slot := findTeamSlot(matches, input.TeamID)
+		if slot == nil {
+			return nil, errDrawEdit{"Team is not in this round's draw"}
+		}
+		previous := matches[idx].RoomID
+		for i := range matches {
+			if i != idx && matches[i].RoomID != nil && *matches[i].RoomID == room.ID {
+				matches[i].RoomID = previous
+			}
+		}
+		roomID := room.ID
+		matches[idx].RoomID = &roomID
+		return nil, nil
+	})
+}
+
+// POST /api/rounds/:id/draw/undo
+// Mengembalikan draw ke posisi sebelum edit terakhir. Body: {"version": 3} (versi ronde)
+func UndoDrawEdit(c *gin.Context) {
+	var input struct {
+		Version *int `json:"version"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	if !isDraftDraw(round) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Draw can only be edited while it is a draft"})
+		return
+	}
+
+	var last models.DrawEdit
+	if err := models.DB.Where("round_id = ?", round.ID).Order("id desc").First(&last).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No draw edits to undo"})
+		return
+	}
+
+	var snapshot []matchSnapshot
+	if err := json.Unmarshal([]byte(last.Snapshot), &snapshot); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid draw edit snapshot"})
+		return
+	}
+
+	matches := make([]models.Match, 0, len(snapshot))
+	for _, s := range snapshot {
+		matches = append(matches, models.Match{Model: gorm.Model{ID: s.ID}, GovTeamID: s.GovTeamID, OppTeamID: s.OppTeamID, RoomID: s.RoomID})
+	}
+
+	tx := models.DB.Begin()
+	if refuseDrawWithResults(c, tx, round.ID) {
+		return
+	}
+	var current []models.Match
+	if err := tx.Where("round_id = ?", round.ID).Find(&current).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	before := make([]matchSnapshot, 0, len(current))
+	for _, match := range current {
+		before = append(before, matchSnapshot{ID: match.ID, GovTeamID: match.GovTeamID, OppTeamID: match.OppTeamID, RoomID: match.RoomID})
+	}
+	if err := bumpRoundVersion(c, tx, &round, *input.Version); err != nil {
+		return
+	}
+	if err := saveMatchPositions(tx, matches, before); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Unscoped().Delete(&last).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	issues, err := validateDraw(tx, round)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	models.DB.Where("round_id = ?", round.ID).Order("id asc").Find(&matches)
+	c.JSON(http.StatusOK, gin.H{"data": matches, "issues": issues, "round_version": round.Version, "message": "Undid " + last.Operation})
+}
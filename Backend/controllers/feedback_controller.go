@@ -79,11 +79,24 @@ func CreateAdjudicatorFeedback(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	if err := db.Create(&feedback).Error; err != nil {
+	tx := db.Begin()
+	if err := tx.Create(&feedback).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create feedback"})
 		return
 	}
 
+	// Feedback baru ikut menggeser skor juri; gagal hitung ulang = feedback batal disimpan
+	var adj models.Adjudicator
+	if err := tx.First(&adj, feedback.AdjudicatorID).Error; err == nil {
+		if err := recalculateAdjudicatorScore(tx, &adj, "feedback", ""); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update adjudicator score: " + err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
 	c.JSON(http.StatusCreated, gin.H{"data": feedback})
 }
 
@@ -169,10 +182,28 @@ func DeleteAdjudicatorFeedback(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	if err := db.Delete(&models.AdjudicatorFeedback{}, id).Error; err != nil {
+	var feedback models.AdjudicatorFeedback
+	if err := db.First(&feedback, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		return
+	}
+
+	tx := db.Begin()
+	if err := tx.Delete(&feedback).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete feedback"})
 		return
 	}
 
+	var adj models.Adjudicator
+	if err := tx.First(&adj, feedback.AdjudicatorID).Error; err == nil {
+		if err := recalculateAdjudicatorScore(tx, &adj, "feedback", "Feedback deleted"); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update adjudicator score: " + err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
 	c.JSON(http.StatusOK, gin.H{"message": "Feedback deleted successfully"})
 }
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Peran juri dalam panel sebuah match
+const (
+	PanelRoleChair     = "chair"
+	PanelRolePanellist = "panellist"
+	PanelRoleTrainee   = "trainee" // Juri bayangan, tidak dihitung dalam ukuran panel
+)
+
+// panelMember: satu juri yang akan dipasang di panel
+type panelMember struct {
+	AdjudicatorID uint
+	Role          string
+}
+
+// errPanel: panel tidak valid. Status 400 untuk input salah, 409 untuk juri yang bentrok.
+type errPanel struct {
+	status         int
+	msg            string
+	adjudicatorIDs []uint
+}
+
+func (e errPanel) Error() string { return e.msg }
+
+// buildPanel menyusun anggota panel dari input chair/wing/trainee dan mengecek ukuran panel.
+// panelSize menghitung chair + panellist (trainee tidak dihitung); 0 berarti bebas.
+func buildPanel(chairID uint, wingIDs, traineeIDs []uint, panelSize int) ([]panelMember, error) {
+	if chairID == 0 {
+		return nil, errPanel{status: http.StatusBadRequest, msg: "chief_adj_id is required"}
+	}
+	if panelSize < 0 {
+		return nil, errPanel{status: http.StatusBadRequest, msg: "panel_size cannot be negative"}
+	}
+	if panelSize > 0 && 1+len(wingIDs) != panelSize {
+		return nil, errPanel{status: http.StatusBadRequest, msg: fmt.Sprintf("Panel size is %d but %d voting adjudicators were given", panelSize, 1+len(wingIDs))}
+	}
+
+	members := []panelMember{{AdjudicatorID: chairID, Role: PanelRoleChair}}
+	for _, id := range wingIDs {
+		members = append(members, panelMember{AdjudicatorID: id, Role: PanelRolePanellist})
+	}
+	for _, id := range traineeIDs {
+		members = append(members, panelMember{AdjudicatorID: id, Role: PanelRoleTrainee})
+	}
+
+	seen := make(map[uint]bool)
+	for _, member := range members {
+		if member.AdjudicatorID == 0 {
+			return nil, errPanel{status: http.StatusBadRequest, msg: "Adjudicator id cannot be 0"}
+		}
+		if seen[member.AdjudicatorID] {
+			return nil, errPanel{status: http.StatusBadRequest, msg: "An adjudicator appears more than once in the panel", adjudicatorIDs: []uint{member.AdjudicatorID}}
+		}
+		seen[member.AdjudicatorID] = true
+	}
+	return members, nil
+}
+
+// validatePanel memastikan semua juri milik turnamen, tersedia di ronde ini,
+// dan belum dipasang di match lain pada ronde yang sama
+func validatePanel(db *gorm.DB, round models.Round, matchID uint, members []panelMember) error {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.AdjudicatorID)
+	}
+
+	var count int64
+	if err := db.Model(&models.Adjudicator{}).Where("id IN ? AND tournament_id = ?", ids, round.TournamentID).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(ids) {
+		return errPanel{status: http.StatusBadRequest, msg: "Some adjudicators do not belong to this tournament"}
+	}
+
+	available, err := roundAvailability(db, round, EntityAdjudicator)
+	if err != nil {
+		return err
+	}
+	var unavailable []uint
+	for _, id := range ids {
+		if !available[id] {
+			unavailable = append(unavailable, id)
+		}
+	}
+	if len(unavailable) > 0 {
+		return errPanel{status: http.StatusBadRequest, msg: "Some adjudicators are not available this round", adjudicatorIDs: unavailable}
+	}
+
+	var booked []uint
+	if err := db.Table("match_adjudicators").
+		Joins("JOIN matches ON matches.id = match_adjudicators.match_id").
+		Where("matches.round_id = ? AND matches.id <> ? AND matches.deleted_at IS NULL AND match_adjudicators.deleted_at IS NULL", round.ID, matchID).
+		Where("match_adjudicators.adjudicator_id IN ?", ids).
+		Distinct().Pluck("match_adjudicators.adjudicator_id", &booked).Error; err != nil {
+		return err
+	}
+	if len(booked) > 0 {
+		return errPanel{status: http.StatusConflict, msg: "Some adjudicators are already allocated to another match in this round", adjudicatorIDs: booked}
+	}
+	return nil
+}
+
+// replaceMatchPanel mengganti seluruh panel match dan menyamakan Match.AdjudicatorID dengan chair
+func replaceMatchPanel(tx *gorm.DB, match models.Match, members []panelMember) error {
+	matchID := match.ID
+	if err := tx.Unscoped().Where("match_id = ?", matchID).Delete(&models.MatchAdjudicator{}).Error; err != nil {
+		return err
+	}
+	var chairID *uint
+	for _, member := range members {
+		row := models.MatchAdjudicator{MatchID: matchID, AdjudicatorID: member.AdjudicatorID, Role: member.Role}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+		if member.Role == PanelRoleChair {
+			id := member.AdjudicatorID
+			chairID = &id
+		}
+	}
+	// Versi match harus masih sama dengan yang dibaca (match.Version), lalu dinaikkan
+	updated, err := updateVersioned(tx, &models.Match{Model: gorm.Model{ID: matchID}}, match.Version, map[string]interface{}{"adjudicator_id": chairID})
+	if err == nil && !updated {
+		err = errStaleVersion
+	}
+	return err
+}
+
+// loadRoundPanels mengambil panel semua match di sebuah ronde, dikelompokkan per match
+func loadRoundPanels(db *gorm.DB, roundID uint) (map[uint][]models.MatchAdjudicator, error) {
+	var rows []models.MatchAdjudicator
+	err := db.Joins("JOIN matches ON matches.id = match_adjudicators.match_id").
+		Where("matches.round_id = ? AND matches.deleted_at IS NULL", roundID).
+		Order("match_adjudicators.id asc").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	panels := make(map[uint][]models.MatchAdjudicator)
+	for _, row := range rows {
+		panels[row.MatchID] = append(panels[row.MatchID], row)
+	}
+	return panels, nil
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// speakerBallotCount menghitung ballot yang sudah tercatat atas nama speaker
+func speakerBallotCount(db *gorm.DB, speakerID uint) int64 {
+	var count int64
+	db.Model(&models.Ballot{}).Where("speaker_id = ?", speakerID).Count(&count)
+	return count
+}
+
+// teamHasResults mengecek apakah tim sudah punya ballot atau hasil match
+func teamHasResults(db *gorm.DB, teamID uint) bool {
+	var ballots int64
+	db.Model(&models.Ballot{}).
+		Joins("JOIN speakers ON speakers.id = ballots.speaker_id").
+		Where("speakers.team_id = ?", teamID).
+		Count(&ballots)
+	if ballots > 0 {
+		return true
+	}
+	var completed int64
+	db.Model(&models.Match{}).
+		Where("is_completed = ? AND (gov_team_id = ? OR opp_team_id = ? OR og_team_id = ? OR oo_team_id = ? OR cg_team_id = ? OR co_team_id = ?)",
+			true, teamID, teamID, teamID, teamID, teamID, teamID).
+		Count(&completed)
+	return completed > 0
+}
+
+// speakerNameTaken mengecek nama speaker ganda dalam satu tim
+func speakerNameTaken(db *gorm.DB, teamID uint, name string, exceptID uint) bool {
+	var count int64
+	db.Model(&models.Speaker{}).Where("team_id = ? AND LOWER(name) = ? AND id <> ?", teamID, strings.ToLower(name), exceptID).Count(&count)
+	return count > 0
+}
+
+// PUT /api/teams/:id
+func UpdateTeam(c *gin.Context) {
+	var team models.Team
+	if err := models.DB.First(&team, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Institution *string `json:"institution"`
+		NeedsAccess *bool   `json:"needs_access"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		var count int64
+		models.DB.Model(&models.Team{}).Where("tournament_id = ? AND LOWER(name) = ? AND id <> ?", team.TournamentID, strings.ToLower(name), team.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another team in this tournament already uses that name"})
+			return
+		}
+		team.Name = name
+	}
+	if input.Institution != nil {
+		team.Institution = strings.TrimSpace(*input.Institution)
+	}
+	if input.NeedsAccess != nil {
+		team.NeedsAccess = *input.NeedsAccess
+	}
+
+	if err := models.DB.Model(&team).Select("name", "institution", "needs_access").Updates(&team).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	models.DB.Preload("Speakers").First(&team, team.ID)
+	c.JSON(http.StatusOK, gin.H{"data": team})
+}
+
+// POST /api/teams/:id/speakers
+func AddTeamSpeaker(c *gin.Context) {
+	var team models.Team
+	if err := models.DB.First(&team, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	var input struct {
+		Name         string `json:"name"`
+		IsSubstitute bool   `json:"is_substitute"` // Cadangan yang boleh menggantikan speaker tim
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if speakerNameTaken(models.DB, team.ID, name, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team already has a speaker with that name"})
+		return
+	}
+
+	speaker := models.Speaker{TeamID: team.ID, Name: name, IsSubstitute: input.IsSubstitute}
+	if err := models.DB.Create(&speaker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": speaker})
+}
+
+// PUT /api/speakers/:id
+// Body: {"name": "..."}, {"is_substitute": true} dan/atau {"team_id": 2} untuk memindahkan speaker ke tim lain.
+// Speaker yang sudah punya ballot tidak bisa dipindah, karena skornya tercatat untuk tim lama.
+func UpdateSpeaker(c *gin.Context) {
+	var speaker models.Speaker
+	if err := models.DB.First(&speaker, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker not found"})
+		return
+	}
+
+	var input struct {
+		Name         *string `json:"name"`
+		TeamID       *uint   `json:"team_id"`
+		IsSubstitute *bool   `json:"is_substitute"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.TeamID != nil && *input.TeamID != speaker.TeamID {
+		var current, target models.Team
+		models.DB.First(&current, speaker.TeamID)
+		if err := models.DB.First(&target, *input.TeamID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target team not found"})
+			return
+		}
+		if target.TournamentID != current.TournamentID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Speakers can only move between teams of the same tournament"})
+			return
+		}
+		if count := speakerBallotCount(models.DB, speaker.ID); count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Speaker already has ballots for their current team", "ballots": count})
+			return
+		}
+		speaker.TeamID = target.ID
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		speaker.Name = name
+	}
+	if input.IsSubstitute != nil {
+		speaker.IsSubstitute = *input.IsSubstitute
+	}
+	if speakerNameTaken(models.DB, speaker.TeamID, speaker.Name, speaker.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team already has a speaker with that name"})
+		return
+	}
+
+	if err := models.DB.Model(&speaker).Select("name", "team_id", "is_substitute").Updates(&speaker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": speaker})
+}
+
+// DELETE /api/speakers/:id
+// Speaker yang sudah punya ballot tidak bisa dihapus (ballot akan kehilangan speaker-nya)
+func DeleteSpeaker(c *gin.Context) {
+	var speaker models.Speaker
+	if err := models.DB.First(&speaker, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Speaker not found"})
+		return
+	}
+	if count := speakerBallotCount(models.DB, speaker.ID); count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Speaker has ballots and cannot be removed", "ballots": count})
+		return
+	}
+	if err := models.DB.Delete(&speaker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Speaker deleted successfully"})
+}
+
+// PUT /api/adjudicators/:id
+// Skor juri diubah lewat PUT /api/adjudicators/:id/score agar tercatat di riwayat
+func UpdateAdjudicator(c *gin.Context) {
+	var adj models.Adjudicator
+	if err := models.DB.First(&adj, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator not found"})
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Institution *string `json:"institution"`
+		Level       *string `json:"level"`
+		IsAvailable *bool   `json:"is_available"`
+		NeedsAccess *bool   `json:"needs_access"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		adj.Name = name
+	}
+	if input.Institution != nil {
+		adj.Institution = strings.TrimSpace(*input.Institution)
+	}
+	if input.Level != nil {
+		adj.Level = *input.Level
+	}
+	if input.IsAvailable != nil {
+		adj.IsAvailable = *input.IsAvailable
+	}
+	if input.NeedsAccess != nil {
+		adj.NeedsAccess = *input.NeedsAccess
+	}
+
+	if err := models.DB.Model(&adj).Select("name", "institution", "level", "is_available", "needs_access").Updates(&adj).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": adj})
+}
+
+// PUT /api/rooms/:id
+func UpdateRoom(c *gin.Context) {
+	var room models.Room
+	if err := models.DB.First(&room, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	var input struct {
+		Name         *string `json:"name"`
+		Location     *string `json:"location"`
+		Capacity     *int    `json:"capacity"`
+		IsAvailable  *bool   `json:"is_available"`
+		Priority     *int    `json:"priority"`
+		IsAccessible *bool   `json:"is_accessible"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		room.Name = name
+	}
+	if input.Location != nil {
+		room.Location = strings.TrimSpace(*input.Location)
+	}
+	if input.Capacity != nil {
+		if *input.Capacity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "capacity cannot be negative"})
+			return
+		}
+		room.Capacity = *input.Capacity
+	}
+	if input.IsAvailable != nil {
+		room.IsAvailable = *input.IsAvailable
+	}
+	if input.Priority != nil {
+		room.Priority = *input.Priority
+	}
+	if input.IsAccessible != nil {
+		room.IsAccessible = *input.IsAccessible
+	}
+
+	if err := models.DB.Model(&room).Select("name", "location", "capacity", "is_available", "priority", "is_accessible").Updates(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": room})
+}
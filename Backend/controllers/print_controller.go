@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// printSheetContext: data bersama semua lembar cetak satu ronde
+type printSheetContext struct {
+	tournament models.Tournament
+	round      models.Round
+	rules      scoreRules
+	speakers   map[uint][]models.Speaker // Per tim: speaker utama dulu, lalu substitute
+}
+
+// speakerPositions: nama posisi pidato per tim (AP 3 speaker), selain itu "Speaker n"
+func speakerPositions(role string, count int) []string {
+	if count == 3 {
+		if role == "gov" {
+			return []string{"PM", "DPM", "GW"}
+		}
+		return []string{"LO", "DLO", "OW"}
+	}
+	positions := make([]string, count)
+	for i := range positions {
+		positions[i] = fmt.Sprintf("Speaker %d", i+1)
+	}
+	return positions
+}
+
+// sheetHeader: identitas debat di bagian atas setiap lembar; mengembalikan posisi y berikutnya
+func sheetHeader(doc *pdfDocument, ctx printSheetContext, title string, match models.Match) float64 {
+	doc.text(50, 60, 16, true, title)
+	doc.text(50, 80, 10, false, ctx.tournament.Name+" - "+ctx.round.Name)
+	room := "-"
+	if match.Room != nil {
+		room = match.Room.Name
+	}
+	doc.text(400, 80, 10, false, "Room: "+room)
+	doc.line(50, 88, 545, 88)
+
+	y := 106.0
+	motion := wrapText(ctx.round.Motion, 85)
+	if len(motion) == 0 {
+		motion = []string{"-"}
+	}
+	doc.text(50, y, 10, true, "Motion:")
+	for _, line := range motion {
+		doc.text(100, y, 10, false, line)
+		y += 14
+	}
+	return y + 6
+}
+
+// drawBallotSheet: satu lembar ballot untuk satu juri di satu match
+func drawBallotSheet(doc *pdfDocument, ctx printSheetContext, match models.Match, adjudicator models.Adjudicator, role string) {
+	doc.addPage()
+	y := sheetHeader(doc, ctx, "BALLOT", match)
+	doc.text(50, y, 10, true, "Adjudicator:")
+	doc.text(120, y, 10, false, fmt.Sprintf("%s (%s)", adjudicator.Name, role))
+	y += 16
+
+	hint := fmt.Sprintf("Skor speaker %g-%g, kelipatan %g", ctx.rules.SpeakerMin, ctx.rules.SpeakerMax, ctx.rules.Step)
+	if ctx.rules.ReplyMax > 0 {
+		hint += fmt.Sprintf("; reply %g-%g (speaker pertama/kedua)", ctx.rules.ReplyMin, ctx.rules.ReplyMax)
+	}
+	if !ctx.rules.AllowLowPointWins {
+		hint += "; pemenang harus bertotal lebih tinggi"
+	}
+	if !ctx.rules.AllowTiedScores {
+		hint += "; tidak boleh seri"
+	}
+	for _, line := range wrapText(hint, 95) {
+		doc.text(50, y, 9, false, line)
+		y += 12
+	}
+	y += 10
+
+	for _, side := range []struct {
+		role  string
+		label string
+		team  *models.Team
+	}{{"gov", "Government", match.GovTeam}, {"opp", "Opposition", match.OppTeam}} {
+		teamName := "-"
+		var speakers []models.Speaker
+		if side.team != nil {
+			teamName = side.team.Name
+			speakers = ctx.speakers[side.team.ID]
+		}
+		doc.text(50, y, 12, true, fmt.Sprintf("%s: %s", side.label, teamName))
+		y += 8
+
+		rows := speakerPositions(side.role, ctx.rules.SpeakersPerSide)
+		if ctx.rules.ReplyMax > 0 {
+			rows = append(rows, "Reply")
+		}
+		rows = append(rows, "Total")
+		for i, position := range rows {
+			doc.rect(50, y, 80, 22)
+			doc.rect(130, y, 315, 22)
+			doc.rect(445, y, 100, 22)
+			doc.text(56, y+15, 10, position == "Total", position)
+			if i < ctx.rules.SpeakersPerSide && i < len(speakers) && !speakers[i].IsSubstitute {
+				doc.text(136, y+15, 10, false, speakers[i].Name)
+			}
+			y += 22
+		}
+		substitutes := []string{}
+		for _, speaker := range speakers {
+			if speaker.IsSubstitute {
+				substitutes = append(substitutes, speaker.Name)
+			}
+		}
+		y += 12
+		if len(substitutes) > 0 {
+			doc.text(50, y, 9, false, "Substitute: "+strings.Join(substitutes, ", "))
+			y += 12
+		}
+		y += 14
+	}
+
+	doc.text(50, y, 11, true, "Pemenang:")
+	doc.rect(130, y-10, 12, 12)
+	doc.text(148, y, 10, false, "Government")
+	doc.rect(250, y-10, 12, 12)
+	doc.text(268, y, 10, false, "Opposition")
+	y += 50
+	doc.line(345, y, 545, y)
+	doc.text(345, y+14, 9, false, "Tanda tangan adjudicator")
+	doc.text(50, 810, 8, false, fmt.Sprintf("match_id %d / adjudicator_id %d", match.ID, adjudicator.ID))
+}
+
+// drawFeedbackForm: formulir feedback satu tim untuk chair match
+func drawFeedbackForm(doc *pdfDocument, ctx printSheetContext, match models.Match, teamRole string, team *models.Team) {
+	doc.addPage()
+	y := sheetHeader(doc, ctx, "ADJUDICATOR FEEDBACK", match)
+	teamName := "-"
+	if team != nil {
+		teamName = team.Name
+	}
+	label := "Government"
+	if teamRole == "opp" {
+		label = "Opposition"
+	}
+	adjudicator := "________________________"
+	adjudicatorID := uint(0)
+	if match.Adjudicator != nil {
+		adjudicator, adjudicatorID = match.Adjudicator.Name, match.Adjudicator.ID
+	}
+	doc.text(50, y, 10, true, "Dari tim:")
+	doc.text(130, y, 10, false, fmt.Sprintf("%s (%s)", teamName, label))
+	y += 16
+	doc.text(50, y, 10, true, "Adjudicator:")
+	doc.text(130, y, 10, false, adjudicator+" (chair)")
+	y += 30
+
+	doc.text(50, y, 11, true, "Rating (1 = sangat buruk, 5 = sangat baik)")
+	y += 20
+	for rating := 1; rating <= 5; rating++ {
+		x := 50 + float64(rating-1)*70
+		doc.rect(x, y-10, 12, 12)
+		doc.text(x+18, y, 11, false, fmt.Sprintf("%d", rating))
+	}
+	y += 36
+
+	doc.text(50, y, 11, true, "Komentar (wajib untuk rating 1-2)")
+	y += 10
+	doc.rect(50, y, 495, 260)
+	for line := y + 26; line < y+260; line += 26 {
+		doc.line(58, line, 537, line)
+	}
+	doc.text(50, 810, 8, false, fmt.Sprintf("match_id %d / adjudicator_id %d / team_role %s", match.ID, adjudicatorID, teamRole))
+}
+
+// GET /api/rounds/:id/print-sheets?sheets=all|ballots|feedback
+// PDF lembar ballot (satu per juri per match) dan formulir feedback tim, hanya untuk draw yang sudah dirilis
+func GetRoundPrintSheets(c *gin.Context) {
+	sheets := c.DefaultQuery("sheets", "all")
+	if sheets != "all" && sheets != "ballots" && sheets != "feedback" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sheets must be 'all', 'ballots' or 'feedback'"})
+		return
+	}
+
+	var ctx printSheetContext
+	if err := models.DB.First(&ctx.round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+	if ctx.round.DrawStatus != DrawStatusReleased {
+		c.JSON(http.StatusConflict, gin.H{"error": "Draw must be released before printing sheets"})
+		return
+	}
+	models.DB.First(&ctx.tournament, ctx.round.TournamentID)
+	settings, err := loadTournamentSettings(models.DB, ctx.round.TournamentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.rules = scoreRulesFor(ctx.tournament.Format, settings)
+
+	var matches []models.Match
+	if err := models.DB.Preload("Room").Preload("GovTeam").Preload("OppTeam").Preload("Adjudicator").
+		Preload("Panel", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).Preload("Panel.Adjudicator").
+		Where("round_id = ? AND is_bye = ? AND is_forfeit = ?", ctx.round.ID, false, false).
+		Order("id asc").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	teamIDs := []uint{}
+	for _, match := range matches {
+		for _, id := range []*uint{match.GovTeamID, match.OppTeamID} {
+			if id != nil {
+				teamIDs = append(teamIDs, *id)
+			}
+		}
+	}
+	var speakers []models.Speaker
+	if err := models.DB.Where("team_id IN ?", teamIDs).Order("is_substitute asc, id asc").Find(&speakers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.speakers = make(map[uint][]models.Speaker)
+	for _, speaker := range speakers {
+		ctx.speakers[speaker.TeamID] = append(ctx.speakers[speaker.TeamID], speaker)
+	}
+
+	doc := &pdfDocument{}
+	if sheets != "feedback" {
+		for _, match := range matches {
+			// Panel lengkap (chair, panellist, trainee); match tanpa panel pakai chair lama
+			if len(match.Panel) > 0 {
+				for _, member := range match.Panel {
+					drawBallotSheet(doc, ctx, match, member.Adjudicator, member.Role)
+				}
+			} else if match.Adjudicator != nil {
+				drawBallotSheet(doc, ctx, match, *match.Adjudicator, PanelRoleChair)
+			}
+		}
+	}
+	if sheets != "ballots" {
+		for _, match := range matches {
+			drawFeedbackForm(doc, ctx, match, "gov", match.GovTeam)
+			drawFeedbackForm(doc, ctx, match, "opp", match.OppTeam)
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=round-%d-sheets.pdf", ctx.round.ID))
+	c.Data(http.StatusOK, "application/pdf", doc.bytes())
+}
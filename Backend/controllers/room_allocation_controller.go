@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+)
+
+// roomRequest: kebutuhan ruangan sebuah match
+type roomRequest struct {
+	MatchID     uint
+	Importance  int      // Bracket match (total VP kedua tim), makin tinggi makin penting
+	NeedsAccess bool     // Ada tim/juri yang butuh ruangan aksesibel
+	Locations   []string // Gedung yang diwajibkan oleh RoomConstraint
+}
+
+func sameLocation(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+func roomSatisfiesLocations(room models.Room, locations []string) bool {
+	for _, location := range locations {
+		if !sameLocation(room.Location, location) {
+			return false
+		}
+	}
+	return true
+}
+
+// allocateRooms membagikan ruangan ke match. Match terpenting dilayani lebih dulu dan
+// mendapat ruangan prioritas tertinggi yang memenuhi kebutuhan aksesibilitas & gedung.
+// Jika tidak ada ruangan yang memenuhi semua syarat, syarat gedung lalu aksesibilitas
+// dilonggarkan dan dicatat sebagai warning.
+func allocateRooms(requests []roomRequest, rooms []models.Room) (map[uint]uint, []drawIssue) {
+	issues := []drawIssue{}
+	ordered := make([]roomRequest, len(requests))
+	copy(ordered, requests)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Importance != ordered[j].Importance {
+			return ordered[i].Importance > ordered[j].Importance
+		}
+		return ordered[i].MatchID < ordered[j].MatchID
+	})
+
+	available := make([]models.Room, len(rooms))
+	copy(available, rooms)
+	sort.SliceStable(available, func(i, j int) bool {
+		if available[i].Priority != available[j].Priority {
+			return available[i].Priority > available[j].Priority
+		}
+		return available[i].Name < available[j].Name
+	})
+
+	used := make(map[uint]bool)
+	pick := func(accept func(models.Room) bool) *models.Room {
+		for i := range available {
+			if !used[available[i].ID] && accept(available[i]) {
+				return &available[i]
+			}
+		}
+		return nil
+	}
+
+	assigned := make(map[uint]uint)
+	for _, req := range ordered {
+		accessOK := func(room models.Room) bool { return !req.NeedsAccess || room.IsAccessible }
+
+		room := pick(func(room models.Room) bool { return accessOK(room) && roomSatisfiesLocations(room, req.Locations) })
+		if room == nil && len(req.Locations) > 0 {
+			room = pick(accessOK)
+			if room != nil {
+				issues = append(issues, drawIssue{Severity: "warning", MatchID: req.MatchID, Message: fmt.Sprintf("No free room in required building (%s)", strings.Join(req.Locations, ", "))})
+			}
+		}
+		if room == nil && req.NeedsAccess {
+			room = pick(func(models.Room) bool { return true })
+			if room != nil {
+				issues = append(issues, drawIssue{Severity: "warning", MatchID: req.MatchID, Message: "No free accessible room"})
+			}
+		}
+		if room == nil {
+			issues = append(issues, drawIssue{Severity: "error", MatchID: req.MatchID, Message: "No room available"})
+			continue
+		}
+		used[room.ID] = true
+		assigned[req.MatchID] = room.ID
+	}
+	return assigned, issues
+}
+
+// POST /api/rounds/:id/allocate-rooms
+// Mengisi ruangan semua match di draft draw secara otomatis (bisa di-undo seperti edit draw lain).
+// Body: {"version": 1} (versi ronde)
+func AllocateRooms(c *gin.Context) {
+	var input struct {
+		Version *int `json:"version"` // Versi ronde yang diedit
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var round models.Round
+	if err := models.DB.First(&round, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
+
+	availableRooms, err := roundAvailability(models.DB, round, EntityRoom)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var allRooms []models.Room
+	if err := models.DB.Where("tournament_id = ?", round.TournamentID).Find(&allRooms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var rooms []models.Room
+	for _, room := range allRooms {
+		if availableRooms[room.ID] {
+			rooms = append(rooms, room)
+		}
+	}
+
+	var constraints []models.RoomConstraint
+	models.DB.Where("tournament_id = ?", round.TournamentID).Find(&constraints)
+	teamLocations := make(map[uint][]string)
+	adjLocations := make(map[uint][]string)
+	for _, constraint := range constraints {
+		if constraint.TeamID != nil {
+			teamLocations[*constraint.TeamID] = append(teamLocations[*constraint.TeamID], constraint.Location)
+		}
+		if constraint.AdjudicatorID != nil {
+			adjLocations[*constraint.AdjudicatorID] = append(adjLocations[*constraint.AdjudicatorID], constraint.Location)
+		}
+	}
+
+	var teams []models.Team
+	models.DB.Where("tournament_id = ?", round.TournamentID).Find(&teams)
+	teamByID := make(map[uint]models.Team)
+	for _, team := range teams {
+		teamByID[team.ID] = team
+	}
+
+	var adjudicators []models.Adjudicator
+	models.DB.Where("tournament_id = ?", round.TournamentID).Find(&adjudicators)
+	adjByID := make(map[uint]models.Adjudicator)
+	for _, adj := range adjudicators {
+		adjByID[adj.ID] = adj
+	}
+
+	panels, err := loadRoundPanels(models.DB, round.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyDrawEdit(c, input.Version, "allocate_rooms", func(matches []models.Match) ([]drawIssue, error) {
+		var requests []roomRequest
+		for _, match := range matches {
+			if match.IsBye {
+				continue
+			}
+			req := roomRequest{MatchID: match.ID}
+			for _, teamID := range []*uint{match.GovTeamID, match.OppTeamID} {
+				if teamID == nil {
+					continue
+				}
+				team := teamByID[*teamID]
+				req.Importance += team.TotalVP
+				req.NeedsAccess = req.NeedsAccess || team.NeedsAccess
+				req.Locations = append(req.Locations, teamLocations[*teamID]...)
+			}
+			for _, member := range panels[match.ID] {
+				req.NeedsAccess = req.NeedsAccess || adjByID[member.AdjudicatorID].NeedsAccess
+				req.Locations = append(req.Locations, adjLocations[member.AdjudicatorID]...)
+			}
+			requests = append(requests, req)
+		}
+
+		assigned, issues := allocateRooms(requests, rooms)
+		for i := range matches {
+			if roomID, ok := assigned[matches[i].ID]; ok {
+				matches[i].RoomID = &roomID
+			} else {
+				matches[i].RoomID = nil
+			}
+		}
+		return issues, nil
+	})
+}
+
+// ROOM CONSTRAINTS
+// GET /api/room-constraints?tournament_id=1
+func GetRoomConstraints(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	var constraints []models.RoomConstraint
+	query := models.DB.Order("id asc")
+	if tournamentID != "" {
+		if _, err := strconv.Atoi(tournamentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+			return
+		}
+		query = query.Where("tournament_id = ?", tournamentID)
+	}
+	if err := query.Find(&constraints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if constraints == nil {
+		constraints = []models.RoomConstraint{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": constraints})
+}
+
+// POST /api/room-constraints
+func CreateRoomConstraint(c *gin.Context) {
+	var input models.RoomConstraint
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (input.TeamID == nil) == (input.AdjudicatorID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of team_id or adjudicator_id is required"})
+		return
+	}
+	if strings.TrimSpace(input.Location) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location is required"})
+		return
+	}
+	if input.TeamID != nil {
+		var team models.Team
+		if err := models.DB.Where("id = ? AND tournament_id = ?", *input.TeamID, input.TournamentID).First(&team).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Team not found in this tournament"})
+			return
+		}
+	}
+	if input.AdjudicatorID != nil {
+		var adj models.Adjudicator
+		if err := models.DB.Where("id = ? AND tournament_id = ?", *input.AdjudicatorID, input.TournamentID).First(&adj).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Adjudicator not found in this tournament"})
+			return
+		}
+	}
+	if err := models.DB.Create(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": input})
+}
+
+// DELETE /api/room-constraints/:id
+func DeleteRoomConstraint(c *gin.Context) {
+	var constraint models.RoomConstraint
+	if err := models.DB.First(&constraint, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room constraint not found"})
+		return
+	}
+	if err := models.DB.Delete(&constraint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Room constraint deleted successfully"})
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// Strategi bye untuk jumlah tim ganjil
+const (
+	ByeStrategyWin   = "bye_win"    // Tim bye otomatis menang dengan rata-rata speaker score
+	ByeStrategySwing = "swing_team" // Dibuatkan swing team, hasilnya tidak masuk klasemen
+)
+
+// Jumlah ballot panel yang dibutuhkan sebelum match selesai, dan pemutus suara seri
+const (
+	BallotsRequiredAll      = "all"      // Semua juri (chair + panellist)
+	BallotsRequiredMajority = "majority" // Mayoritas panel
+	TieBreakChair           = "chair"    // Suara chair menentukan
+	TieBreakScores          = "scores"   // Total skor rata-rata tim menentukan, lalu chair
+)
+
+// Perlakuan speaker score tim yang menang forfeit
+const (
+	ForfeitSpeakerAverage = "average" // Rata-rata speaker score dari debat lain (seperti bye)
+	ForfeitSpeakerNone    = "none"    // Tidak mendapat speaker score
+)
+
+// Skor tab speaker iron-person (satu speaker mengisi dua posisi)
+const (
+	IronPersonFirst   = "first"   // Hanya posisi pertama
+	IronPersonHighest = "highest" // Skor tertinggi dari dua posisi
+	IronPersonBoth    = "both"    // Kedua skor dijumlahkan
+)
+
+// loadTournamentSettings mengambil pengaturan turnamen, membuat default jika belum ada
+func loadTournamentSettings(db *gorm.DB, tournamentID uint) (models.TournamentSettings, error) {
+	settings := models.TournamentSettings{TournamentID: tournamentID}
+	err := db.Where("tournament_id = ?", tournamentID).
+		Attrs(models.TournamentSettings{ByeStrategy: ByeStrategyWin, FeedbackWeight: 0.5, FeedbackFullWeightAfter: 3,
+			ForfeitWinPoints: 1, ForfeitSpeakerScores: ForfeitSpeakerAverage,
+			BallotsRequired: BallotsRequiredAll, PanelTieBreak: TieBreakChair, IronPersonTab: IronPersonFirst}).
+		FirstOrCreate(&settings).Error
+	return settings, err
+}
+
+// GET /api/tournaments/:id/settings
+func GetTournamentSettings(c *gin.Context) {
+	var tournament models.Tournament
+	if err := models.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	settings, err := loadTournamentSettings(models.DB, tournament.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings, "score_rules": scoreRulesFor(tournament.Format, settings)})
+}
+
+// PUT /api/tournaments/:id/settings
+func UpdateTournamentSettings(c *gin.Context) {
+	var tournament models.Tournament
+	if err := models.DB.First(&tournament, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	var input struct {
+		ByeStrategy               *string  `json:"bye_strategy"`
+		FeedbackWeight            *float64 `json:"feedback_weight"`
+		FeedbackFullWeightAfter   *int     `json:"feedback_full_weight_after"`
+		RequireCheckIn            *bool    `json:"require_check_in"`
+		ForfeitWinPoints          *int     `json:"forfeit_win_points"`
+		ForfeitSpeakerScores      *string  `json:"forfeit_speaker_scores"`
+		BallotsRequired           *string  `json:"ballots_required"`
+		PanelTieBreak             *string  `json:"panel_tie_break"`
+		RequireBallotConfirmation *bool    `json:"require_ballot_confirmation"`
+		SpeakerScoreMin           *float64 `json:"speaker_score_min"`
+		SpeakerScoreMax           *float64 `json:"speaker_score_max"`
+		ReplyScoreMin             *float64 `json:"reply_score_min"`
+		ReplyScoreMax             *float64 `json:"reply_score_max"`
+		ScoreStep                 *float64 `json:"score_step"`
+		SpeakersPerSide           *int     `json:"speakers_per_side"`
+		AllowLowPointWins         *bool    `json:"allow_low_point_wins"`
+		AllowTiedScores           *bool    `json:"allow_tied_scores"`
+		IronPersonTab             *string  `json:"iron_person_tab"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := loadTournamentSettings(models.DB, tournament.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.ByeStrategy != nil {
+		if *input.ByeStrategy != ByeStrategyWin && *input.ByeStrategy != ByeStrategySwing {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bye_strategy must be 'bye_win' or 'swing_team'"})
+			return
+		}
+		settings.ByeStrategy = *input.ByeStrategy
+	}
+	if input.FeedbackWeight != nil {
+		if *input.FeedbackWeight < 0 || *input.FeedbackWeight > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "feedback_weight must be between 0 and 1"})
+			return
+		}
+		settings.FeedbackWeight = *input.FeedbackWeight
+	}
+	if input.FeedbackFullWeightAfter != nil {
+		if *input.FeedbackFullWeightAfter < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "feedback_full_weight_after cannot be negative"})
+			return
+		}
+		settings.FeedbackFullWeightAfter = *input.FeedbackFullWeightAfter
+	}
+	if input.RequireCheckIn != nil {
+		settings.RequireCheckIn = *input.RequireCheckIn
+	}
+	if input.ForfeitWinPoints != nil {
+		if *input.ForfeitWinPoints < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "forfeit_win_points cannot be negative"})
+			return
+		}
+		settings.ForfeitWinPoints = *input.ForfeitWinPoints
+	}
+	if input.ForfeitSpeakerScores != nil {
+		if *input.ForfeitSpeakerScores != ForfeitSpeakerAverage && *input.ForfeitSpeakerScores != ForfeitSpeakerNone {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "forfeit_speaker_scores must be 'average' or 'none'"})
+			return
+		}
+		settings.ForfeitSpeakerScores = *input.ForfeitSpeakerScores
+	}
+	if input.BallotsRequired != nil {
+		if *input.BallotsRequired != BallotsRequiredAll && *input.BallotsRequired != BallotsRequiredMajority {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ballots_required must be 'all' or 'majority'"})
+			return
+		}
+		settings.BallotsRequired = *input.BallotsRequired
+	}
+	if input.PanelTieBreak != nil {
+		if *input.PanelTieBreak != TieBreakChair && *input.PanelTieBreak != TieBreakScores {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "panel_tie_break must be 'chair' or 'scores'"})
+			return
+		}
+		settings.PanelTieBreak = *input.PanelTieBreak
+	}
+	if input.RequireBallotConfirmation != nil {
+		settings.RequireBallotConfirmation = *input.RequireBallotConfirmation
+	}
+	for _, field := range []struct {
+		name  string
+		value *float64
+		dest  *float64
+	}{
+		{"speaker_score_min", input.SpeakerScoreMin, &settings.SpeakerScoreMin},
+		{"speaker_score_max", input.SpeakerScoreMax, &settings.SpeakerScoreMax},
+		{"reply_score_min", input.ReplyScoreMin, &settings.ReplyScoreMin},
+		{"reply_score_max", input.ReplyScoreMax, &settings.ReplyScoreMax},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": field.name + " cannot be negative"})
+			return
+		}
+		*field.dest = *field.value
+	}
+	if settings.SpeakerScoreMin > 0 && settings.SpeakerScoreMax > 0 && settings.SpeakerScoreMin > settings.SpeakerScoreMax {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "speaker_score_min cannot exceed speaker_score_max"})
+		return
+	}
+	if settings.ReplyScoreMin > 0 && settings.ReplyScoreMax > 0 && settings.ReplyScoreMin > settings.ReplyScoreMax {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reply_score_min cannot exceed reply_score_max"})
+		return
+	}
+	if input.ScoreStep != nil {
+		if *input.ScoreStep != 0 && *input.ScoreStep != 0.5 && *input.ScoreStep != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "score_step must be 1 (whole points) or 0.5 (half points)"})
+			return
+		}
+		settings.ScoreStep = *input.ScoreStep
+	}
+	if input.SpeakersPerSide != nil {
+		if *input.SpeakersPerSide < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "speakers_per_side cannot be negative"})
+			return
+		}
+		settings.SpeakersPerSide = *input.SpeakersPerSide
+	}
+	if input.AllowLowPointWins != nil {
+		settings.AllowLowPointWins = *input.AllowLowPointWins
+	}
+	if input.AllowTiedScores != nil {
+		settings.AllowTiedScores = *input.AllowTiedScores
+	}
+	if input.IronPersonTab != nil {
+		if *input.IronPersonTab != IronPersonFirst && *input.IronPersonTab != IronPersonHighest && *input.IronPersonTab != IronPersonBoth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "iron_person_tab must be 'first', 'highest' or 'both'"})
+			return
+		}
+		settings.IronPersonTab = *input.IronPersonTab
+	}
+
+	if err := models.DB.Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
 )
 
 // 1. Buat Turnamen Baru
@@ -83,10 +86,23 @@ func DeleteTeam(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
 		return
 	}
-	if err := models.DB.Delete(&team).Error; err != nil {
+	// Tim yang sudah punya hasil tidak boleh dihapus, ballot-nya akan yatim
+	if teamHasResults(models.DB, team.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team already has results and cannot be deleted"})
+		return
+	}
+	tx := models.DB.Begin()
+	if err := tx.Where("team_id = ?", team.ID).Delete(&models.Speaker{}).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := tx.Delete(&team).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"message": "Team deleted successfully"})
 }
 
@@ -123,11 +139,15 @@ func PublishDraw(c *gin.Context) {
 	id := c.Param("id")
 	var input struct {
 		IsDrawPublished bool `json:"is_draw_published"`
+		Version         *int `json:"version"` // Versi ronde yang diedit
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
 
 	var round models.Round
 	if err := models.DB.First(&round, id).Error; err != nil {
@@ -135,11 +155,16 @@ func PublishDraw(c *gin.Context) {
 		return
 	}
 
-	round.IsDrawPublished = input.IsDrawPublished
-	if err := models.DB.Save(&round).Error; err != nil {
+	updated, err := updateVersioned(models.DB, &round, *input.Version, map[string]interface{}{"is_draw_published": input.IsDrawPublished})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	models.DB.First(&round, round.ID)
+	if !updated {
+		staleVersion(c, round)
+		return
+	}
 
 	message := "Draw published to users"
 	if !input.IsDrawPublished {
@@ -153,11 +178,15 @@ func PublishMotion(c *gin.Context) {
 	id := c.Param("id")
 	var input struct {
 		IsMotionPublished bool `json:"is_motion_published"`
+		Version           *int `json:"version"` // Versi ronde yang diedit
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
 
 	var round models.Round
 	if err := models.DB.First(&round, id).Error; err != nil {
@@ -165,11 +194,16 @@ func PublishMotion(c *gin.Context) {
 		return
 	}
 
-	round.IsMotionPublished = input.IsMotionPublished
-	if err := models.DB.Save(&round).Error; err != nil {
+	updated, err := updateVersioned(models.DB, &round, *input.Version, map[string]interface{}{"is_motion_published": input.IsMotionPublished})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	models.DB.First(&round, round.ID)
+	if !updated {
+		staleVersion(c, round)
+		return
+	}
 
 	message := "Motion published to users"
 	if !input.IsMotionPublished {
@@ -232,7 +266,7 @@ func GetTeams(c *gin.Context) {
 func GetSpeakers(c *gin.Context) {
 	teamID := c.Query("team_id")
 	var speakers []models.Speaker
-	query := models.DB.Preload("Team").Order("created_at asc")
+	query := models.DB.Preload("Team").Preload("Categories").Order("created_at asc")
 
 	if teamID != "" {
 		if _, err := strconv.Atoi(teamID); err != nil {
@@ -288,12 +322,16 @@ func GetRounds(c *gin.Context) {
 func UpdateRoundStatus(c *gin.Context) {
 	roundID := c.Param("id")
 	var input struct {
-		Status string `json:"status"` // "completed" or "in_progress"
+		Status  string `json:"status"`  // "completed" or "in_progress"
+		Version *int   `json:"version"` // Versi ronde yang diedit
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
 
 	// Validate status
 	if input.Status != "completed" && input.Status != "in_progress" {
@@ -307,11 +345,16 @@ func UpdateRoundStatus(c *gin.Context) {
 		return
 	}
 
-	round.Status = input.Status
-	if err := models.DB.Save(&round).Error; err != nil {
+	updated, err := updateVersioned(models.DB, &round, *input.Version, map[string]interface{}{"status": input.Status})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	models.DB.First(&round, round.ID)
+	if !updated {
+		staleVersion(c, round)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": round, "message": "Round status updated"})
 }
@@ -324,6 +367,7 @@ func CreateMatch(c *gin.Context) {
 		OppTeamID     uint `json:"opp_team_id"`
 		RoomID        uint `json:"room_id"`
 		AdjudicatorID uint `json:"adjudicator_id"`
+		IsBye         bool `json:"is_bye"` // Bye: cukup gov_team_id, tim otomatis menang
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -331,6 +375,38 @@ func CreateMatch(c *gin.Context) {
 	}
 	// Debug log
 	fmt.Printf("CreateMatch received: %+v\n", input)
+	if input.IsBye {
+		if input.GovTeamID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "gov_team_id is required for a bye"})
+			return
+		}
+		var round models.Round
+		if err := models.DB.First(&round, input.RoundID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+			return
+		}
+		match := models.Match{
+			RoundID:     input.RoundID,
+			GovTeamID:   &input.GovTeamID,
+			WinnerID:    &input.GovTeamID,
+			IsBye:       true,
+			IsCompleted: true,
+		}
+		tx := models.DB.Begin()
+		if err := tx.Create(&match).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := recalculateStandings(tx, round.TournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tx.Commit()
+		c.JSON(http.StatusOK, gin.H{"data": match})
+		return
+	}
 	match := models.Match{
 		RoundID:       input.RoundID,
 		GovTeamID:     &input.GovTeamID,
@@ -339,11 +415,22 @@ func CreateMatch(c *gin.Context) {
 		AdjudicatorID: &input.AdjudicatorID,
 		IsCompleted:   false,
 	}
-	if err := models.DB.Create(&match).Error; err != nil {
+	tx := models.DB.Begin()
+	if err := tx.Create(&match).Error; err != nil {
+		tx.Rollback()
 		println("CreateMatch DB error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Juri yang dipilih saat membuat match langsung menjadi chair panel
+	if input.AdjudicatorID != 0 {
+		if err := tx.Create(&models.MatchAdjudicator{MatchID: match.ID, AdjudicatorID: input.AdjudicatorID, Role: PanelRoleChair}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"data": match})
 }
 
@@ -352,7 +439,9 @@ func GetMatches(c *gin.Context) {
 	roundID := c.Query("round_id")           // Filter per ronde
 	tournamentID := c.Query("tournament_id") // Filter per tournament
 	var matches []models.Match
-	query := models.DB.Preload("GovTeam").Preload("OppTeam").Preload("Round").Preload("Room").Preload("Adjudicator").Order("id asc")
+	query := models.DB.Preload("GovTeam").Preload("OppTeam").Preload("Round").Preload("Room").Preload("Adjudicator").
+		Preload("Panel", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).Preload("Panel.Adjudicator").
+		Order("id asc")
 
 	if roundID != "" {
 		if _, err := strconv.Atoi(roundID); err != nil {
@@ -405,12 +494,16 @@ func UpdateMatchResult(c *gin.Context) {
 	var input struct {
 		WinnerID    uint `json:"winner_id"`
 		IsCompleted bool `json:"is_completed"`
+		Version     *int `json:"version"` // Versi match yang diedit
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
 
 	var match models.Match
 	if err := models.DB.First(&match, matchID).Error; err != nil {
@@ -418,62 +511,119 @@ func UpdateMatchResult(c *gin.Context) {
 		return
 	}
 
-	// Update match result
-	match.WinnerID = &input.WinnerID
-	match.IsCompleted = input.IsCompleted
-
-	if err := models.DB.Save(&match).Error; err != nil {
+	// Update match result, ditolak jika match sudah diubah sejak versi yang diedit
+	updated, err := updateVersioned(models.DB, &match, *input.Version, map[string]interface{}{
+		"winner_id":    &input.WinnerID,
+		"is_completed": input.IsCompleted,
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	models.DB.First(&match, match.ID)
+	if !updated {
+		staleVersion(c, match)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": match})
 }
 
 // 11b. ASSIGN ADJUDICATOR PANEL TO MATCH
+// Body: {"chief_adj_id": 1, "wing_adj_ids": [2,3], "trainee_adj_ids": [4], "panel_size": 3}
+// Panel dengan konflik juri ditolak (409) kecuali "allow_conflict": true
 func AssignAdjudicatorPanel(c *gin.Context) {
 	matchID := c.Param("id")
 	var input struct {
-		ChiefAdjID uint   `json:"chief_adj_id"`
-		WingAdjIDs []uint `json:"wing_adj_ids"`
-		PanelSize  int    `json:"panel_size"`
+		ChiefAdjID    uint   `json:"chief_adj_id"`
+		WingAdjIDs    []uint `json:"wing_adj_ids"`
+		TraineeAdjIDs []uint `json:"trainee_adj_ids"`
+		PanelSize     int    `json:"panel_size"`     // Jumlah juri yang memberi suara (chair + wing), 0 = bebas
+		AllowConflict bool   `json:"allow_conflict"` // Tetap pasang panel walau ada konflik juri
+		Version       *int   `json:"version"`        // Versi match yang diedit
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireVersion(c, input.Version) {
+		return
+	}
 
 	var match models.Match
 	if err := models.DB.First(&match, matchID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
 		return
 	}
+	var round models.Round
+	if err := models.DB.First(&round, match.RoundID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Round not found"})
+		return
+	}
 
-	// Set the chief adjudicator
-	match.AdjudicatorID = &input.ChiefAdjID
+	members, err := buildPanel(input.ChiefAdjID, input.WingAdjIDs, input.TraineeAdjIDs, input.PanelSize)
+	if err == nil {
+		err = validatePanel(models.DB, round, match.ID, members)
+	}
+	if err != nil {
+		var panelErr errPanel
+		if errors.As(err, &panelErr) {
+			c.JSON(panelErr.status, gin.H{"error": panelErr.msg, "adjudicator_ids": panelErr.adjudicatorIDs})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
 
-	// Store wing adjudicators as comma-separated IDs in PanelJudges
-	if len(input.WingAdjIDs) > 0 {
-		wingIDsStr := ""
-		for i, id := range input.WingAdjIDs {
-			if i > 0 {
-				wingIDsStr += ","
-			}
-			wingIDsStr += fmt.Sprintf("%d", id)
+	if !input.AllowConflict {
+		index, err := loadConflicts(models.DB, round.TournamentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		adjIDs := make([]uint, 0, len(members))
+		for _, member := range members {
+			adjIDs = append(adjIDs, member.AdjudicatorID)
+		}
+		if conflicts := index.panelConflicts(matchTeamIDs(match), adjIDs); len(conflicts) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Panel has adjudicator conflicts", "conflicts": conflicts})
+			return
 		}
-		match.PanelJudges = wingIDsStr
-	} else {
-		match.PanelJudges = ""
 	}
 
-	if err := models.DB.Save(&match).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	tx := models.DB.Begin()
+	match.Version = *input.Version
+	if err := replaceMatchPanel(tx, match, members); err != nil {
+		tx.Rollback()
+		if errors.Is(err, errStaleVersion) {
+			models.DB.First(&match, match.ID)
+			staleVersion(c, match)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
+	// Ballot sudah masuk: hasil match dihitung ulang dari ballot juri yang masih di panel
+	var counted int64
+	tx.Model(&models.BallotSet{}).Where("match_id = ? AND status = ?", match.ID, BallotConfirmed).Count(&counted)
+	if counted > 0 {
+		if err := lockMatch(tx, &match, match.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := applyMatchDecision(tx, &match, round.TournamentID); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
 
 	// Reload match with adjudicator data
-	models.DB.Preload("Adjudicator").First(&match, matchID)
+	models.DB.Preload("Adjudicator").Preload("Panel", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("Panel.Adjudicator").First(&match, match.ID)
 
 	c.JSON(http.StatusOK, gin.H{"data": match, "message": "Adjudicator panel assigned successfully"})
 }
@@ -485,10 +635,23 @@ func CreateAdjudicator(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := models.DB.Create(&input).Error; err != nil {
+	if !validAdjScore(input.BaseScore) || (input.ScoreOverride != nil && !validAdjScore(*input.ScoreOverride)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Adjudicator scores must be between 0 and 10"})
+		return
+	}
+	tx := models.DB.Begin()
+	if err := tx.Create(&input).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Skor efektif selalu dihitung dari base score / override
+	if err := recalculateAdjudicatorScore(tx, &input, "base", ""); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"data": input})
 }
 
@@ -587,10 +750,30 @@ func DeleteAdjudicator(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Adjudicator not found"})
 		return
 	}
-	if err := models.DB.Delete(&adjudicator).Error; err != nil {
+	var ballots int64
+	models.DB.Model(&models.Ballot{}).Where("adjudicator_id = ?", adjudicator.ID).Count(&ballots)
+	if ballots > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Adjudicator has submitted ballots and cannot be deleted", "ballots": ballots})
+		return
+	}
+	// Lepas juri dari panel yang masih menunjuknya
+	tx := models.DB.Begin()
+	if err := tx.Where("adjudicator_id = ?", adjudicator.ID).Delete(&models.MatchAdjudicator{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Model(&models.Match{}).Where("adjudicator_id = ?", adjudicator.ID).Update("adjudicator_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Delete(&adjudicator).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"message": "Adjudicator deleted successfully"})
 }
 
@@ -619,6 +802,7 @@ func DeleteMatch(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	models.DB.Where("match_id = ?", match.ID).Delete(&models.MatchAdjudicator{})
 	c.JSON(http.StatusOK, gin.H{"message": "Match deleted successfully"})
 }
 
@@ -714,7 +898,7 @@ func ImportTeamsCSV(c *gin.Context) {
 }
 
 // CSV Import Adjudicators
-// Format CSV: name,institution
+// Format CSV: name,institution,base_score (base_score = skor tes CA 0-10, opsional)
 func ImportAdjudicatorsCSV(c *gin.Context) {
 	tournamentID := c.Query("tournament_id")
 	if tournamentID == "" {
@@ -754,11 +938,21 @@ func ImportAdjudicatorsCSV(c *gin.Context) {
 		if len(row) > 1 {
 			institution = row[1]
 		}
+		baseScore := 0.0
+		if len(row) > 2 && strings.TrimSpace(row[2]) != "" {
+			baseScore, err = strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+			if err != nil || !validAdjScore(baseScore) {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid base_score '%s' for adjudicator '%s'", row[2], adjName)})
+				return
+			}
+		}
 
 		adj := models.Adjudicator{
 			TournamentID: uint(tid),
 			Name:         adjName,
 			Institution:  institution,
+			BaseScore:    baseScore,
 		}
 
 		if err := tx.Create(&adj).Error; err != nil {
@@ -766,6 +960,11 @@ func ImportAdjudicatorsCSV(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create adjudicator '%s': %s", adjName, err.Error())})
 			return
 		}
+		if err := recalculateAdjudicatorScore(tx, &adj, "base", "CSV import"); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		created++
 	}
 
@@ -777,7 +976,7 @@ func ImportAdjudicatorsCSV(c *gin.Context) {
 }
 
 // CSV Import Rooms
-// Format CSV: name,capacity (capacity is optional)
+// Format CSV: name,capacity,location,priority,accessible (semua kolom selain name opsional)
 func ImportRoomsCSV(c *gin.Context) {
 	tournamentID := c.Query("tournament_id")
 	if tournamentID == "" {
@@ -824,6 +1023,15 @@ func ImportRoomsCSV(c *gin.Context) {
 			Name:         roomName,
 			Capacity:     capacity,
 		}
+		if len(row) > 2 {
+			room.Location = row[2]
+		}
+		if len(row) > 3 {
+			room.Priority, _ = strconv.Atoi(row[3])
+		}
+		if len(row) > 4 {
+			room.IsAccessible = row[4] == "true" || row[4] == "1" || row[4] == "yes"
+		}
 
 		if err := tx.Create(&room).Error; err != nil {
 			tx.Rollback()
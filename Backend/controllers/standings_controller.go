@@ -3,14 +3,21 @@ package controllers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
 )
 
-// GET /api/standings/teams?tournament_id=1
+// GET /api/standings/teams?tournament_id=1[&category_id=2]
+// Dengan category_id hanya tim yang semua speaker-nya masuk kategori, dengan peringkat di kategori itu
 func GetStandings(c *gin.Context) {
 	tournamentID := c.Query("tournament_id")
+	category, ok := loadCategoryFilter(c)
+	if !ok {
+		return
+	}
 	var teams []models.Team
 
 	if tournamentID != "" {
@@ -28,7 +35,7 @@ func GetStandings(c *gin.Context) {
 		// Get opposition team IDs from matches in rounds of this tournament
 		models.DB.Table("matches").
 			Joins("JOIN rounds ON matches.round_id = rounds.id").
-			Where("rounds.tournament_id = ?", tournamentID).
+			Where("rounds.tournament_id = ? AND matches.opp_team_id IS NOT NULL", tournamentID).
 			Pluck("DISTINCT opp_team_id", &oppTeamIDs)
 
 		// Combine and deduplicate team IDs
@@ -47,17 +54,32 @@ func GetStandings(c *gin.Context) {
 
 		if len(participatingTeamIDs) > 0 {
 			// Get teams that actually participated
-			models.DB.Where("id IN ?", participatingTeamIDs).
+			models.DB.Where("id IN ? AND is_swing = ?", participatingTeamIDs, false).
 				Order("total_vp desc").Order("total_speaker desc").
 				Find(&teams)
 		} else {
 			// Fallback to original method if no matches found
-			models.DB.Where("tournament_id = ?", tournamentID).
+			models.DB.Where("tournament_id = ? AND is_swing = ?", tournamentID, false).
 				Order("total_vp desc").Order("total_speaker desc").
 				Find(&teams)
 		}
 	} else {
-		models.DB.Order("total_vp desc").Order("total_speaker desc").Find(&teams)
+		models.DB.Where("is_swing = ?", false).Order("total_vp desc").Order("total_speaker desc").Find(&teams)
+	}
+
+	if category != nil {
+		eligible, err := categoryEligibleTeams(models.DB, *category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filtered := []models.Team{}
+		for _, team := range teams {
+			if eligible[team.ID] {
+				filtered = append(filtered, team)
+			}
+		}
+		teams = filtered
 	}
 
 	// Update Ranking Angka (1, 2, 3...) secara manual sebelum dikirim
@@ -73,19 +95,28 @@ func GetStandings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": teams})
 }
 
-// GET /api/standings/speakers?tournament_id=1
+// GET /api/standings/speakers?tournament_id=1[&category_id=2]
 func GetSpeakerStandings(c *gin.Context) {
 	tournamentID := c.Query("tournament_id")
+	category, ok := loadCategoryFilter(c)
+	if !ok {
+		return
+	}
 	var speakers []models.Speaker
 
 	// Join with Team to filter by tournament_id
 	query := models.DB.Joins("JOIN teams ON teams.id = speakers.team_id").
 		Select("speakers.*, teams.name as team_name, teams.institution as institution").
+		Where("teams.is_swing = ?", false).
 		Order("speakers.total_score desc")
 
 	if tournamentID != "" {
 		query = query.Where("teams.tournament_id = ?", tournamentID)
 	}
+	if category != nil {
+		query = query.Where("speakers.id IN (?)", models.DB.Model(&models.SpeakerCategoryAssignment{}).
+			Select("speaker_id").Where("speaker_category_id = ?", category.ID))
+	}
 
 	if err := query.Find(&speakers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -104,6 +135,53 @@ func GetSpeakerStandings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": speakers})
 }
 
+// replyStanding: satu baris tab reply speaker
+type replyStanding struct {
+	Rank         int     `json:"rank"`
+	SpeakerID    uint    `json:"speaker_id"`
+	Name         string  `json:"name"`
+	TeamID       uint    `json:"team_id"`
+	TeamName     string  `json:"team_name"`
+	Institution  string  `json:"institution"`
+	ReplyTotal   float64 `json:"reply_total"`
+	ReplyCount   int     `json:"reply_count"`
+	ReplyAverage float64 `json:"reply_average"`
+}
+
+// GET /api/standings/replies?tournament_id=1[&category_id=2]
+// Tab reply speaker, diurutkan dari rata-rata skor reply (jumlah reply tiap speaker berbeda)
+func GetReplyStandings(c *gin.Context) {
+	tournamentID := c.Query("tournament_id")
+	category, ok := loadCategoryFilter(c)
+	if !ok {
+		return
+	}
+
+	query := models.DB.Table("speakers").
+		Joins("JOIN teams ON teams.id = speakers.team_id").
+		Select("speakers.id as speaker_id, speakers.name, speakers.team_id, teams.name as team_name, teams.institution, "+
+			"speakers.reply_total, speakers.reply_count, speakers.reply_total / speakers.reply_count as reply_average").
+		Where("speakers.deleted_at IS NULL AND teams.is_swing = ? AND speakers.reply_count > 0", false).
+		Order("reply_average desc, speakers.reply_total desc")
+	if tournamentID != "" {
+		query = query.Where("teams.tournament_id = ?", tournamentID)
+	}
+	if category != nil {
+		query = query.Where("speakers.id IN (?)", models.DB.Model(&models.SpeakerCategoryAssignment{}).
+			Select("speaker_id").Where("speaker_category_id = ?", category.ID))
+	}
+
+	standings := []replyStanding{}
+	if err := query.Scan(&standings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	c.JSON(http.StatusOK, gin.H{"data": standings})
+}
+
 // GET /api/institutions?tournament_id=1
 func GetParticipatingInstitutions(c *gin.Context) {
 	tournamentID := c.Query("tournament_id")
@@ -123,7 +201,7 @@ func GetParticipatingInstitutions(c *gin.Context) {
 			Select("teams.institution, COUNT(DISTINCT teams.id) as team_count, SUM(teams.total_vp) as total_points, AVG(teams.total_vp) as avg_points").
 			Joins("JOIN matches ON teams.id = matches.gov_team_id OR teams.id = matches.opp_team_id").
 			Joins("JOIN rounds ON matches.round_id = rounds.id").
-			Where("rounds.tournament_id = ?", tournamentID).
+			Where("rounds.tournament_id = ? AND teams.is_swing = ?", tournamentID, false).
 			Group("teams.institution").
 			Order("total_points desc").
 			Scan(&institutions)
@@ -131,6 +209,7 @@ func GetParticipatingInstitutions(c *gin.Context) {
 		// Get all institutions from teams table
 		models.DB.Table("teams").
 			Select("teams.institution, COUNT(teams.id) as team_count, SUM(teams.total_vp) as total_points, AVG(teams.total_vp) as avg_points").
+			Where("teams.is_swing = ?", false).
 			Group("teams.institution").
 			Order("total_points desc").
 			Scan(&institutions)
@@ -151,109 +230,224 @@ func RecalculateStandings(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id diperlukan"})
 		return
 	}
+	tid, err := strconv.Atoi(tournamentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tournament_id tidak valid"})
+		return
+	}
 
 	tx := models.DB.Begin()
-
-	// 1. Reset semua team stats untuk tournament ini
-	if err := tx.Model(&models.Team{}).
-		Where("tournament_id = ?", tournamentID).
-		Updates(map[string]interface{}{
-			"total_vp":      0,
-			"total_speaker": 0,
-			"wins":          0,
-			"losses":        0,
-		}).Error; err != nil {
+	processed, err := recalculateStandings(tx, uint(tid))
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal reset team stats"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 2. Reset semua speaker scores untuk tournament ini
-	if err := tx.Exec(`
-		UPDATE speakers SET total_score = 0 
-		WHERE team_id IN (SELECT id FROM teams WHERE tournament_id = ?)
-	`, tournamentID).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal reset speaker scores"})
-		return
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Standings berhasil dihitung ulang",
+		"matches_processed": processed,
+	})
+}
+
+// recalculateStandings mereset lalu menghitung ulang statistik tim & speaker satu turnamen
+// dari ballot yang tersimpan. Mengembalikan jumlah match completed yang diproses.
+//
+// Match bye diproses paling akhir: tim bye mendapat 1 VP dan speaker score rata-rata dari
+// debat lain yang sudah dijalani. Statistik swing team tidak dihitung, tapi lawannya tetap
+// mendapat hasil normal. Match forfeit memberi pemenang settings.ForfeitWinPoints VP dan
+// speaker score sesuai settings.ForfeitSpeakerScores; tim yang mundur dicatat kalah.
+func recalculateStandings(tx *gorm.DB, tournamentID uint) (int, error) {
+	settings, err := loadTournamentSettings(tx, tournamentID)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil settings: %w", err)
 	}
 
-	// 3. Ambil semua match yang sudah completed di tournament ini
+	// 1. Ambil semua tim & speaker di tournament ini (statistik dimulai dari 0)
+	var teams []models.Team
+	if err := tx.Where("tournament_id = ?", tournamentID).Find(&teams).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil tim: %w", err)
+	}
+	teamStats := make(map[uint]*models.Team)
+	var teamIDs []uint
+	for i := range teams {
+		teams[i].TotalVP, teams[i].TotalSpeaker, teams[i].Wins, teams[i].Losses = 0, 0, 0, 0
+		teamStats[teams[i].ID] = &teams[i]
+		teamIDs = append(teamIDs, teams[i].ID)
+	}
+
+	var speakers []models.Speaker
+	if len(teamIDs) > 0 {
+		if err := tx.Where("team_id IN ?", teamIDs).Find(&speakers).Error; err != nil {
+			return 0, fmt.Errorf("gagal mengambil speaker: %w", err)
+		}
+	}
+	speakerStats := make(map[uint]*models.Speaker)
+	for i := range speakers {
+		speakers[i].TotalScore, speakers[i].ReplyTotal, speakers[i].ReplyCount = 0, 0, 0
+		speakerStats[speakers[i].ID] = &speakers[i]
+	}
+
+	// 2. Ambil semua match yang sudah completed di tournament ini
 	var completedMatches []models.Match
-	if err := tx.Preload("GovTeam").Preload("OppTeam").
-		Joins("JOIN rounds ON matches.round_id = rounds.id").
+	if err := tx.Joins("JOIN rounds ON matches.round_id = rounds.id").
 		Where("rounds.tournament_id = ? AND matches.is_completed = ?", tournamentID, true).
 		Find(&completedMatches).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil completed matches"})
-		return
+		return 0, fmt.Errorf("gagal mengambil completed matches: %w", err)
 	}
 
 	fmt.Printf("Debug Recalculate: Found %d completed matches\n", len(completedMatches))
 
-	// 4. Untuk setiap match, hitung ulang stats dari ballot
+	teamDebates := make(map[uint]int)
+	speakerDebates := make(map[uint]int)
+
+	applyTeam := func(teamID *uint, total float64, winnerID *uint) {
+		if teamID == nil {
+			return
+		}
+		team, ok := teamStats[*teamID]
+		if !ok || team.IsSwing {
+			return
+		}
+		team.TotalSpeaker += total
+		if winnerID != nil && *winnerID == *teamID {
+			team.TotalVP += 1
+			team.Wins += 1
+		} else {
+			team.Losses += 1
+		}
+		teamDebates[team.ID]++
+	}
+
+	// Bye hanya dihitung di ronde yang draw-nya sudah dirilis; bye di draft draw belum berlaku
+	var releasedRounds []uint
+	if err := tx.Model(&models.Round{}).Where("tournament_id = ? AND draw_status = ?", tournamentID, DrawStatusReleased).
+		Pluck("id", &releasedRounds).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil ronde yang dirilis: %w", err)
+	}
+	released := make(map[uint]bool)
+	for _, id := range releasedRounds {
+		released[id] = true
+	}
+
+	// 3. Untuk setiap match (selain bye), hitung ulang stats dari ballot
+	var byes, forfeits []models.Match
 	for _, match := range completedMatches {
-		// Ambil ballots untuk match ini
-		var ballots []models.Ballot
-		tx.Where("match_id = ?", match.ID).Find(&ballots)
-
-		var totalGov, totalOpp int
-		speakerScores := make(map[uint]int)
-
-		for _, ballot := range ballots {
-			if ballot.TeamRole == "gov" {
-				totalGov += ballot.Score
-			} else if ballot.TeamRole == "opp" {
-				totalOpp += ballot.Score
+		if match.IsForfeit {
+			forfeits = append(forfeits, match)
+			continue
+		}
+		if match.IsBye {
+			if released[match.RoundID] {
+				byes = append(byes, match)
 			}
-			if ballot.SpeakerID != 0 {
-				speakerScores[ballot.SpeakerID] += ballot.Score
+			continue
+		}
+
+		// Skor dari rata-rata ballot juri mayoritas di panel
+		decision, err := loadMatchDecision(tx, match, settings)
+		if err != nil {
+			return 0, fmt.Errorf("gagal mengambil ballot match %d: %w", match.ID, err)
+		}
+
+		applyTeam(match.GovTeamID, decision.GovTotal, match.WinnerID)
+		applyTeam(match.OppTeamID, decision.OppTotal, match.WinnerID)
+
+		for speakerID, score := range decision.SpeakerScores {
+			speaker, ok := speakerStats[speakerID]
+			if !ok || teamStats[speaker.TeamID].IsSwing {
+				continue
 			}
+			speaker.TotalScore += score
+			speakerDebates[speakerID]++
 		}
+		for speakerID, score := range decision.ReplyScores {
+			speaker, ok := speakerStats[speakerID]
+			if !ok || teamStats[speaker.TeamID].IsSwing {
+				continue
+			}
+			speaker.ReplyTotal += score
+			speaker.ReplyCount++
+		}
+	}
 
-		// Update Gov Team
-		if match.GovTeamID != nil {
-			var govTeam models.Team
-			if err := tx.First(&govTeam, *match.GovTeamID).Error; err == nil {
-				govTeam.TotalSpeaker += totalGov
-				if match.WinnerID != nil && *match.WinnerID == *match.GovTeamID {
-					govTeam.TotalVP += 1
-					govTeam.Wins += 1
-				} else {
-					govTeam.Losses += 1
-				}
-				tx.Save(&govTeam)
+	// 4. Bye: menang otomatis + rata-rata speaker score dari debat lain
+	teamAverage := make(map[uint]float64)
+	for id, team := range teamStats {
+		if teamDebates[id] > 0 {
+			teamAverage[id] = team.TotalSpeaker / float64(teamDebates[id])
+		}
+	}
+	speakerAverage := make(map[uint]float64)
+	for id, speaker := range speakerStats {
+		if speakerDebates[id] > 0 {
+			speakerAverage[id] = speaker.TotalScore / float64(speakerDebates[id])
+		}
+	}
+	awardAverage := func(team *models.Team) {
+		team.TotalSpeaker += teamAverage[team.ID]
+		for id, speaker := range speakerStats {
+			if speaker.TeamID == team.ID {
+				speaker.TotalScore += speakerAverage[id]
 			}
 		}
+	}
+	for _, bye := range byes {
+		if bye.GovTeamID == nil {
+			continue
+		}
+		team, ok := teamStats[*bye.GovTeamID]
+		if !ok || team.IsSwing {
+			continue
+		}
+		team.TotalVP += 1
+		team.Wins += 1
+		awardAverage(team)
+	}
 
-		// Update Opp Team
-		if match.OppTeamID != nil {
-			var oppTeam models.Team
-			if err := tx.First(&oppTeam, *match.OppTeamID).Error; err == nil {
-				oppTeam.TotalSpeaker += totalOpp
-				if match.WinnerID != nil && *match.WinnerID == *match.OppTeamID {
-					oppTeam.TotalVP += 1
-					oppTeam.Wins += 1
-				} else {
-					oppTeam.Losses += 1
+	// 4b. Forfeit: lawan tim yang mundur menang, tim yang mundur kalah tanpa speaker score
+	for _, match := range forfeits {
+		for _, teamID := range []*uint{match.GovTeamID, match.OppTeamID} {
+			if teamID == nil {
+				continue
+			}
+			team, ok := teamStats[*teamID]
+			if !ok || team.IsSwing {
+				continue
+			}
+			if match.WinnerID != nil && *match.WinnerID == team.ID {
+				team.TotalVP += settings.ForfeitWinPoints
+				team.Wins += 1
+				if settings.ForfeitSpeakerScores == ForfeitSpeakerAverage {
+					awardAverage(team)
 				}
-				tx.Save(&oppTeam)
+			} else {
+				team.Losses += 1
 			}
 		}
+	}
 
-		// Update Speaker Scores
-		for speakerID, score := range speakerScores {
-			var speaker models.Speaker
-			if err := tx.First(&speaker, speakerID).Error; err == nil {
-				speaker.TotalScore += score
-				tx.Save(&speaker)
-			}
+	// 5. Simpan hasil
+	for _, team := range teams {
+		if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(map[string]interface{}{
+			"total_vp":      team.TotalVP,
+			"total_speaker": team.TotalSpeaker,
+			"wins":          team.Wins,
+			"losses":        team.Losses,
+		}).Error; err != nil {
+			return 0, fmt.Errorf("gagal update team stats: %w", err)
+		}
+	}
+	for _, speaker := range speakers {
+		if err := tx.Model(&models.Speaker{}).Where("id = ?", speaker.ID).Updates(map[string]interface{}{
+			"total_score": speaker.TotalScore,
+			"reply_total": speaker.ReplyTotal,
+			"reply_count": speaker.ReplyCount,
+		}).Error; err != nil {
+			return 0, fmt.Errorf("gagal update speaker scores: %w", err)
 		}
 	}
 
-	tx.Commit()
-	c.JSON(http.StatusOK, gin.H{
-		"message":           "Standings berhasil dihitung ulang",
-		"matches_processed": len(completedMatches),
-	})
+	return len(completedMatches), nil
 }
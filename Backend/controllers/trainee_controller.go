@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// isTraineeOnMatch mengecek apakah juri terpasang sebagai trainee di panel match
+func isTraineeOnMatch(db *gorm.DB, matchID, adjudicatorID uint) bool {
+	if adjudicatorID == 0 {
+		return false
+	}
+	var count int64
+	db.Model(&models.MatchAdjudicator{}).
+		Where("match_id = ? AND adjudicator_id = ? AND role = ?", matchID, adjudicatorID, PanelRoleTrainee).
+		Count(&count)
+	return count > 0
+}
+
+// traineeComparison: ballot satu trainee dibandingkan dengan keputusan resmi panel
+type traineeComparison struct {
+	MatchID         uint          `json:"match_id"`
+	AdjudicatorID   uint          `json:"adjudicator_id"`
+	Adjudicator     string        `json:"adjudicator"`
+	TraineeWinner   string        `json:"trainee_winner"`
+	PanelWinner     string        `json:"panel_winner"` // Kosong jika match belum punya hasil resmi
+	AgreesWithPanel bool          `json:"agrees_with_panel"`
+	TraineeGov      float64       `json:"trainee_gov"`
+	TraineeOpp      float64       `json:"trainee_opp"`
+	PanelGov        float64       `json:"panel_gov"`
+	PanelOpp        float64       `json:"panel_opp"`
+	AvgScoreDiff    float64       `json:"avg_score_diff"` // Rata-rata selisih absolut skor per speaker
+	Speakers        []speakerDiff `json:"speakers"`
+}
+
+type speakerDiff struct {
+	SpeakerID    uint    `json:"speaker_id"`
+	Position     string  `json:"position"`
+	IsReply      bool    `json:"is_reply"`
+	TraineeScore float64 `json:"trainee_score"`
+	PanelScore   float64 `json:"panel_score"` // Rata-rata skor juri resmi
+}
+
+// traineeSummary: rekap per trainee di seluruh match yang dibandingkan
+type traineeSummary struct {
+	AdjudicatorID uint    `json:"adjudicator_id"`
+	Adjudicator   string  `json:"adjudicator"`
+	Ballots       int     `json:"ballots"`
+	Agreements    int     `json:"agreements"`
+	AgreementRate float64 `json:"agreement_rate"`
+	AvgScoreDiff  float64 `json:"avg_score_diff"`
+}
+
+func winnerRole(match models.Match) string {
+	if match.WinnerID == nil {
+		return ""
+	}
+	if match.GovTeamID != nil && *match.WinnerID == *match.GovTeamID {
+		return "gov"
+	}
+	if match.OppTeamID != nil && *match.WinnerID == *match.OppTeamID {
+		return "opp"
+	}
+	return ""
+}
+
+// GET /api/trainee-report?round_id=1 atau ?tournament_id=1 (opsional &adjudicator_id=2)
+// Membandingkan keputusan & skor trainee dengan keputusan resmi panel
+func GetTraineeReport(c *gin.Context) {
+	query := models.DB.Model(&models.Match{}).Order("matches.id asc")
+	if roundID := c.Query("round_id"); roundID != "" {
+		if _, err := strconv.Atoi(roundID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid round_id"})
+			return
+		}
+		query = query.Where("round_id = ?", roundID)
+	} else if tournamentID := c.Query("tournament_id"); tournamentID != "" {
+		if _, err := strconv.Atoi(tournamentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+			return
+		}
+		query = query.Joins("JOIN rounds ON rounds.id = matches.round_id").Where("rounds.tournament_id = ?", tournamentID)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "round_id or tournament_id is required"})
+		return
+	}
+
+	var matches []models.Match
+	if err := query.Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	matchByID := make(map[uint]models.Match)
+	matchIDs := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		matchByID[match.ID] = match
+		matchIDs = append(matchIDs, match.ID)
+	}
+
+	comparisons := []traineeComparison{}
+	summaries := []traineeSummary{}
+	if len(matchIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": comparisons, "summary": summaries})
+		return
+	}
+
+	ballotQuery := models.DB.Preload("Adjudicator").Where("match_id IN ?", matchIDs).Where(countedBallotsClause, BallotConfirmed).Order("id asc")
+	if adjID := c.Query("adjudicator_id"); adjID != "" {
+		if _, err := strconv.Atoi(adjID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid adjudicator_id"})
+			return
+		}
+		ballotQuery = ballotQuery.Where("(is_trainee = ? OR adjudicator_id = ?)", false, adjID)
+	}
+	var ballots []models.Ballot
+	if err := ballotQuery.Find(&ballots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Skor resmi per match & speaker: rata-rata semua juri non-trainee
+	type official struct {
+		sum   map[string]float64
+		count map[string]int
+		adjs  map[uint]bool
+		gov   float64
+		opp   float64
+	}
+	officialByMatch := make(map[uint]*official)
+	type traineeKey struct{ matchID, adjID uint }
+	traineeBallots := make(map[traineeKey][]models.Ballot)
+	var traineeOrder []traineeKey
+	slotKey := func(b models.Ballot) string {
+		return strconv.Itoa(int(b.SpeakerID)) + "|" + b.Position + "|" + strconv.FormatBool(b.IsReply)
+	}
+	for _, ballot := range ballots {
+		if ballot.IsTrainee {
+			key := traineeKey{ballot.MatchID, ballot.AdjudicatorID}
+			if _, ok := traineeBallots[key]; !ok {
+				traineeOrder = append(traineeOrder, key)
+			}
+			traineeBallots[key] = append(traineeBallots[key], ballot)
+			continue
+		}
+		o := officialByMatch[ballot.MatchID]
+		if o == nil {
+			o = &official{sum: map[string]float64{}, count: map[string]int{}, adjs: map[uint]bool{}}
+			officialByMatch[ballot.MatchID] = o
+		}
+		o.sum[slotKey(ballot)] += ballot.Score
+		o.count[slotKey(ballot)]++
+		o.adjs[ballot.AdjudicatorID] = true
+		if ballot.TeamRole == "gov" {
+			o.gov += ballot.Score
+		} else if ballot.TeamRole == "opp" {
+			o.opp += ballot.Score
+		}
+	}
+
+	summaryByAdj := make(map[uint]*traineeSummary)
+	diffTotals := make(map[uint]float64)
+	diffCounts := make(map[uint]int)
+	for _, key := range traineeOrder {
+		entries := traineeBallots[key]
+		match := matchByID[key.matchID]
+		comparison := traineeComparison{
+			MatchID:       key.matchID,
+			AdjudicatorID: key.adjID,
+			Adjudicator:   entries[0].Adjudicator.Name,
+			TraineeWinner: entries[0].Winner,
+			PanelWinner:   winnerRole(match),
+			Speakers:      []speakerDiff{},
+		}
+
+		o := officialByMatch[key.matchID]
+		panelCount := 1.0
+		if o != nil && len(o.adjs) > 0 {
+			panelCount = float64(len(o.adjs))
+			comparison.PanelGov = o.gov / panelCount
+			comparison.PanelOpp = o.opp / panelCount
+		}
+
+		diffSum, diffCount := 0.0, 0
+		for _, ballot := range entries {
+			if ballot.TeamRole == "gov" {
+				comparison.TraineeGov += ballot.Score
+			} else {
+				comparison.TraineeOpp += ballot.Score
+			}
+			entry := speakerDiff{SpeakerID: ballot.SpeakerID, Position: ballot.Position, IsReply: ballot.IsReply, TraineeScore: ballot.Score}
+			if o != nil && o.count[slotKey(ballot)] > 0 {
+				entry.PanelScore = o.sum[slotKey(ballot)] / float64(o.count[slotKey(ballot)])
+				diffSum += math.Abs(ballot.Score - entry.PanelScore)
+				diffCount++
+			}
+			comparison.Speakers = append(comparison.Speakers, entry)
+		}
+		if comparison.TraineeWinner == "" {
+			comparison.TraineeWinner = "opp"
+			if comparison.TraineeGov > comparison.TraineeOpp {
+				comparison.TraineeWinner = "gov"
+			}
+		}
+		comparison.AgreesWithPanel = comparison.PanelWinner != "" && comparison.PanelWinner == comparison.TraineeWinner
+		if diffCount > 0 {
+			comparison.AvgScoreDiff = math.Round(diffSum/float64(diffCount)*100) / 100
+		}
+		comparisons = append(comparisons, comparison)
+
+		summary := summaryByAdj[key.adjID]
+		if summary == nil {
+			summary = &traineeSummary{AdjudicatorID: key.adjID, Adjudicator: comparison.Adjudicator}
+			summaryByAdj[key.adjID] = summary
+		}
+		summary.Ballots++
+		if comparison.AgreesWithPanel {
+			summary.Agreements++
+		}
+		diffTotals[key.adjID] += diffSum
+		diffCounts[key.adjID] += diffCount
+	}
+
+	for adjID, summary := range summaryByAdj {
+		summary.AgreementRate = math.Round(float64(summary.Agreements)/float64(summary.Ballots)*100) / 100
+		if diffCounts[adjID] > 0 {
+			summary.AvgScoreDiff = math.Round(diffTotals[adjID]/float64(diffCounts[adjID])*100) / 100
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Adjudicator < summaries[j].Adjudicator })
+
+	c.JSON(http.StatusOK, gin.H{"data": comparisons, "summary": summaries})
+}
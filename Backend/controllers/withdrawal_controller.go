@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/star_fj/eds-backend/models"
+	"gorm.io/gorm"
+)
+
+// withdrawnInRound mengecek apakah tim sudah mundur di ronde ini (urutan ronde mengikuti ID)
+func withdrawnInRound(team models.Team, round models.Round) bool {
+	return team.Withdrawn && team.WithdrawnRoundID != nil && round.ID >= *team.WithdrawnRoundID
+}
+
+// forfeitMatch menandai match sebagai forfeit: lawan tim yang mundur menang tanpa debat.
+// Bye milik tim yang mundur tetap tercatat tapi tanpa pemenang.
+func forfeitMatch(tx *gorm.DB, match *models.Match, teamID uint) error {
+	var winnerID *uint
+	if !match.IsBye {
+		if match.GovTeamID != nil && *match.GovTeamID == teamID {
+			winnerID = match.OppTeamID
+		} else {
+			winnerID = match.GovTeamID
+		}
+	}
+	match.IsForfeit = true
+	match.IsCompleted = true
+	match.WinnerID = winnerID
+	updated, err := updateVersioned(tx, match, match.Version, map[string]interface{}{
+		"is_forfeit": true, "is_completed": true, "winner_id": winnerID,
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errStaleVersion
+	}
+	match.Version++
+	return nil
+}
+
+// POST /api/teams/:id/withdraw
+// Body: {"round_id": 3, "reason": "..."}. Tim tidak ikut draw mulai round_id; match tim itu di
+// ronde yang draw-nya sudah dirilis (dan belum ada hasil) menjadi forfeit. Draw yang belum dirilis
+// dan masih memuat tim ini dikembalikan di "draws_to_regenerate".
+func WithdrawTeam(c *gin.Context) {
+	var input struct {
+		RoundID uint   `json:"round_id"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var team models.Team
+	if err := models.DB.First(&team, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+	if team.IsSwing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Swing teams cannot be withdrawn"})
+		return
+	}
+	if team.Withdrawn {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team has already withdrawn", "withdrawn_round_id": team.WithdrawnRoundID})
+		return
+	}
+	var round models.Round
+	if err := models.DB.First(&round, input.RoundID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "round_id not found"})
+		return
+	}
+	if round.TournamentID != team.TournamentID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Round does not belong to the team's tournament"})
+		return
+	}
+
+	var matches []models.Match
+	if err := models.DB.Preload("Round").
+		Joins("JOIN rounds ON rounds.id = matches.round_id").
+		Where("rounds.tournament_id = ? AND matches.round_id >= ? AND (matches.gov_team_id = ? OR matches.opp_team_id = ?)",
+			team.TournamentID, round.ID, team.ID, team.ID).
+		Order("matches.round_id asc").
+		Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	team.Withdrawn = true
+	team.WithdrawnRoundID = &round.ID
+	team.WithdrawnAt = &now
+	team.WithdrawalReason = strings.TrimSpace(input.Reason)
+
+	tx := models.DB.Begin()
+	if err := tx.Model(&team).Select("withdrawn", "withdrawn_round_id", "withdrawn_at", "withdrawal_reason").Updates(&team).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	forfeited := []uint{}
+	regenerate := []uint{}
+	seenRound := make(map[uint]bool)
+	for i := range matches {
+		match := &matches[i]
+		if match.IsCompleted {
+			continue // Hasil yang sudah ada tetap berlaku
+		}
+		if match.Round == nil || match.Round.DrawStatus != DrawStatusReleased {
+			if !seenRound[match.RoundID] {
+				seenRound[match.RoundID] = true
+				regenerate = append(regenerate, match.RoundID)
+			}
+			continue
+		}
+		if err := forfeitMatch(tx, match, team.ID); err != nil {
+			tx.Rollback()
+			c.JSON(versionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		forfeited = append(forfeited, match.ID)
+	}
+
+	if _, err := recalculateStandings(tx, team.TournamentID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	models.DB.First(&team, team.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":                team,
+		"forfeited_matches":   forfeited,
+		"draws_to_regenerate": regenerate,
+	})
+}
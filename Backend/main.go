@@ -83,21 +83,43 @@ func main() {
 		api.POST("/tournaments", controllers.CreateTournament)
 		api.PUT("/tournaments/:id", controllers.UpdateTournament)
 		api.DELETE("/tournaments/:id", controllers.DeleteTournament)
+		api.GET("/tournaments/:id/settings", controllers.GetTournamentSettings)
+		api.PUT("/tournaments/:id/settings", controllers.UpdateTournamentSettings)
+		api.POST("/tournaments/:id/adjudicator-scores/recalculate", controllers.RecalculateAdjudicatorScores)
 
 		// Tim
 		api.GET("/teams", controllers.GetTeams)    // <--- API untuk melihat daftar tim
 		api.POST("/teams", controllers.CreateTeam) // <--- API untuk mendaftarkan tim baru
+		api.PUT("/teams/:id", controllers.UpdateTeam)
 		api.DELETE("/teams/:id", controllers.DeleteTeam)
-		api.POST("/teams/import-csv", controllers.ImportTeamsCSV) // <--- Import dari CSV
+		api.POST("/teams/:id/speakers", controllers.AddTeamSpeaker) // <--- Tambah speaker ke tim
+		api.POST("/teams/:id/withdraw", controllers.WithdrawTeam)   // <--- Tim mundur (forfeit + keluar dari draw)
+		api.POST("/teams/import-csv", controllers.ImportTeamsCSV)   // <--- Import dari CSV
 
 		// Speakers
 		api.GET("/speakers", controllers.GetSpeakers) // <--- API untuk melihat daftar speaker berdasarkan team_id
+		api.GET("/speakers/:id/check-in-code", controllers.GetSpeakerCheckInCode)
+		api.PUT("/speakers/:id", controllers.UpdateSpeaker) // <--- Ganti nama / pindah tim
+		api.DELETE("/speakers/:id", controllers.DeleteSpeaker)
+		api.PUT("/speakers/:id/categories", controllers.UpdateSpeakerCategories)
+
+		// Kategori speaker (Novice, ESL, ...)
+		api.GET("/speaker-categories", controllers.GetSpeakerCategories)
+		api.POST("/speaker-categories", controllers.CreateSpeakerCategory)
+		api.PUT("/speaker-categories/:id", controllers.UpdateSpeakerCategory)
+		api.DELETE("/speaker-categories/:id", controllers.DeleteSpeakerCategory)
+		api.POST("/speaker-categories/import-csv", controllers.ImportSpeakerCategoriesCSV) // <--- Import dari CSV
 
 		// Ronde & Match
 
 		// --- INPUT SKOR (TABULATOR) ---
 		api.POST("/ballots", controllers.SubmitBallot)
+		api.POST("/ballots/batch", controllers.SubmitBallotBatch) // Antrean ballot offline
 		api.GET("/ballots", controllers.GetBallots)
+		api.POST("/ballot-sets/:id/confirm", controllers.ConfirmBallotSet) // Konfirmasi oleh user lain
+		api.POST("/ballot-sets/:id/discard", controllers.DiscardBallotSet)
+		api.GET("/ballot-sets/:id/discrepancies", controllers.GetBallotSetDiscrepancies) // Perbandingan double entry
+		api.GET("/trainee-report", controllers.GetTraineeReport)                         // Perbandingan ballot trainee vs keputusan panel
 
 		// RONDE
 		api.GET("/rounds", controllers.GetRounds)
@@ -106,30 +128,68 @@ func main() {
 		api.PUT("/rounds/:id/publish-draw", controllers.PublishDraw)
 		api.PUT("/rounds/:id/publish-motion", controllers.PublishMotion)
 		api.PUT("/rounds/:id/status", controllers.UpdateRoundStatus)
+		api.POST("/rounds/:id/generate-draw", controllers.GenerateDraw)  // Power-paired draw (AP)
+		api.PUT("/rounds/:id/draw-status", controllers.UpdateDrawStatus) // draft -> confirmed -> released
+		api.POST("/rounds/:id/draw/preview", controllers.PreviewDraw)    // Dry-run draw (tanpa simpan)
+		api.GET("/rounds/:id/draw/validate", controllers.ValidateDraw)
+		api.POST("/rounds/:id/draw/swap-teams", controllers.SwapDrawTeams)
+		api.POST("/rounds/:id/draw/flip-sides", controllers.FlipDrawSides)
+		api.POST("/rounds/:id/draw/move-team", controllers.MoveDrawTeam)
+		api.POST("/rounds/:id/draw/undo", controllers.UndoDrawEdit)
+		api.POST("/rounds/:id/allocate-rooms", controllers.AllocateRooms)               // Alokasi ruangan otomatis
+		api.POST("/rounds/:id/allocate-adjudicators", controllers.AllocateAdjudicators) // Alokasi juri otomatis
+		api.GET("/rounds/:id/allocation-diagnostics", controllers.GetAllocationDiagnostics)
+		api.GET("/rounds/:id/check-ins", controllers.GetCheckIns)
+		api.PUT("/rounds/:id/check-ins", controllers.UpdateCheckIns)
+		api.POST("/rounds/:id/check-ins/scan", controllers.ScanCheckIn) // Scan QR code peserta
+		api.GET("/rounds/:id/availability", controllers.GetRoundAvailability)
+		api.GET("/rounds/:id/ballot-progress", controllers.GetRoundBallotProgress) // Dashboard ballot yang belum masuk
+		api.GET("/rounds/:id/print-sheets", controllers.GetRoundPrintSheets)       // PDF ballot & feedback kertas
+		api.PUT("/rounds/:id/availability", controllers.UpdateRoundAvailability)   // Bulk toggle tim/juri/ruangan
 
 		// MATCHES
 		api.GET("/matches", controllers.GetMatches)
 		api.POST("/matches", controllers.CreateMatch)
 		api.PUT("/matches/:id/result", controllers.UpdateMatchResult)
 		api.PUT("/matches/:id/panel", controllers.AssignAdjudicatorPanel)
+		api.PUT("/matches/:id/importance", controllers.UpdateMatchImportance)
+		api.GET("/matches/:id/ballots", controllers.GetMatchBallotSets) // <--- Ballot per juri + keputusan panel
+		api.GET("/matches/:id/ballot-versions", controllers.GetBallotVersions)
+		api.GET("/matches/:id/ballot-versions/diff", controllers.DiffBallotVersions) // ?from=1&to=2
+		api.POST("/matches/:id/ballot-versions/:revision/rollback", controllers.RollbackBallotVersion)
 		api.DELETE("/matches/:id", controllers.DeleteMatch)
 
 		// ADJUDICATORS
 		api.GET("/adjudicators", controllers.GetAdjudicators)
 		api.POST("/adjudicators", controllers.CreateAdjudicator)
+		api.PUT("/adjudicators/:id", controllers.UpdateAdjudicator)
 		api.DELETE("/adjudicators/:id", controllers.DeleteAdjudicator)
 		api.POST("/adjudicators/import-csv", controllers.ImportAdjudicatorsCSV) // <--- Import dari CSV
+		api.GET("/adjudicators/ranking", controllers.GetAdjudicatorRanking)
+		api.PUT("/adjudicators/:id/score", controllers.UpdateAdjudicatorScore)
+		api.GET("/adjudicators/:id/score-history", controllers.GetAdjudicatorScoreHistory)
+		api.GET("/adjudicators/:id/check-in-code", controllers.GetAdjudicatorCheckInCode)
+		api.GET("/adjudicator-conflicts", controllers.GetAdjudicatorConflicts)
+		api.POST("/adjudicator-conflicts", controllers.CreateAdjudicatorConflict)
+		api.PUT("/adjudicator-conflicts/:id", controllers.UpdateAdjudicatorConflict)
+		api.DELETE("/adjudicator-conflicts/:id", controllers.DeleteAdjudicatorConflict)
+		api.POST("/adjudicator-conflicts/import-csv", controllers.ImportAdjudicatorConflictsCSV)
 
 		// ROOMS
 		api.GET("/rooms", controllers.GetRooms)
 		api.POST("/rooms", controllers.CreateRoom)
+		api.PUT("/rooms/:id", controllers.UpdateRoom)
 		api.DELETE("/rooms/:id", controllers.DeleteRoom)
 		api.POST("/rooms/import-csv", controllers.ImportRoomsCSV) // <--- Import dari CSV
+		api.GET("/room-constraints", controllers.GetRoomConstraints)
+		api.POST("/room-constraints", controllers.CreateRoomConstraint) // "Tim X harus di gedung Y"
+		api.DELETE("/room-constraints/:id", controllers.DeleteRoomConstraint)
 
 		// STANDINGS (KLASEMEN)
 		api.GET("/standings", controllers.GetStandings) // Legacy support if needed
 		api.GET("/standings/teams", controllers.GetStandings)
 		api.GET("/standings/speakers", controllers.GetSpeakerStandings)
+		api.GET("/standings/replies", controllers.GetReplyStandings) // Tab reply speaker
 		api.POST("/standings/recalculate", controllers.RecalculateStandings)
 
 		// INSTITUTIONS
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrateLegacyPanels memindahkan kolom lama matches.panel_judges (ID wing dipisah koma)
+// ke tabel match_adjudicators, lalu menghapus kolom tersebut.
+func migrateLegacyPanels(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&Match{}, "panel_judges") {
+		return nil
+	}
+
+	var legacy []struct {
+		ID            uint
+		AdjudicatorID *uint
+		PanelJudges   string
+	}
+	if err := db.Table("matches").Select("id, adjudicator_id, panel_judges").Scan(&legacy).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range legacy {
+			var existing int64
+			tx.Model(&MatchAdjudicator{}).Where("match_id = ?", row.ID).Count(&existing)
+			if existing > 0 {
+				continue
+			}
+			if row.AdjudicatorID != nil && *row.AdjudicatorID != 0 {
+				if err := tx.Create(&MatchAdjudicator{MatchID: row.ID, AdjudicatorID: *row.AdjudicatorID, Role: "chair"}).Error; err != nil {
+					return err
+				}
+			}
+			for _, part := range strings.Split(row.PanelJudges, ",") {
+				id, err := strconv.Atoi(strings.TrimSpace(part))
+				if err != nil || id == 0 {
+					continue
+				}
+				if err := tx.Create(&MatchAdjudicator{MatchID: row.ID, AdjudicatorID: uint(id), Role: "panellist"}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Exec("ALTER TABLE matches DROP COLUMN panel_judges").Error
+	})
+}
+
+// migrateLegacyBallots mengelompokkan ballot lama (sebelum ada BallotSet) menjadi satu
+// ballot set per juri per match
+func migrateLegacyBallots(db *gorm.DB) error {
+	var legacy []Ballot
+	if err := db.Where("ballot_set_id = 0 OR ballot_set_id IS NULL").Order("id asc").Find(&legacy).Error; err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	type setKey struct {
+		matchID, adjudicatorID uint
+		isTrainee              bool
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		sets := make(map[setKey]*BallotSet)
+		for _, ballot := range legacy {
+			key := setKey{ballot.MatchID, ballot.AdjudicatorID, ballot.IsTrainee}
+			set, ok := sets[key]
+			if !ok {
+				set = &BallotSet{MatchID: ballot.MatchID, AdjudicatorID: ballot.AdjudicatorID, Winner: ballot.Winner, IsTrainee: ballot.IsTrainee}
+				if err := tx.Create(set).Error; err != nil {
+					return err
+				}
+				sets[key] = set
+			}
+			if ballot.TeamRole == "gov" {
+				set.GovTotal += ballot.Score
+			} else if ballot.TeamRole == "opp" {
+				set.OppTotal += ballot.Score
+			}
+			if err := tx.Model(&Ballot{}).Where("id = ?", ballot.ID).Update("ballot_set_id", set.ID).Error; err != nil {
+				return err
+			}
+		}
+		for _, set := range sets {
+			if set.Winner == "" {
+				set.Winner = "opp"
+				if set.GovTotal > set.OppTotal {
+					set.Winner = "gov"
+				}
+			}
+			if err := tx.Model(set).Select("winner", "gov_total", "opp_total").Updates(set).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// numberBallotRevisions memberi nomor versi ke ballot set lama yang belum bernomor
+// (urut ID per match), lalu mengisi versi yang berlaku di tiap match
+func numberBallotRevisions(db *gorm.DB) error {
+	var sets []BallotSet
+	if err := db.Where("revision = 0 AND status <> ?", "draft").Order("id asc").Find(&sets).Error; err != nil {
+		return err
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		next := make(map[uint]int)
+		for _, set := range sets {
+			if _, ok := next[set.MatchID]; !ok {
+				var max int
+				tx.Model(&BallotSet{}).Where("match_id = ?", set.MatchID).Select("COALESCE(MAX(revision), 0)").Scan(&max)
+				next[set.MatchID] = max
+			}
+			next[set.MatchID]++
+			if err := tx.Model(&BallotSet{}).Where("id = ?", set.ID).Update("revision", next[set.MatchID]).Error; err != nil {
+				return err
+			}
+		}
+		for matchID := range next {
+			var current int
+			tx.Model(&BallotSet{}).Where("match_id = ? AND status = ? AND is_trainee = ?", matchID, "confirmed", false).
+				Select("COALESCE(MAX(revision), 0)").Scan(&current)
+			if err := tx.Model(&Match{}).Where("id = ?", matchID).Update("current_ballot_revision", current).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// renumberDuplicateRevisions dijalankan sebelum AutoMigrate: nomor versi ganda dari submit
+// bersamaan (sebelum ada unique index) diberi nomor baru di akhir, set dengan ID terkecil
+// tetap memakai nomornya. Versi yang berlaku di match dihitung ulang.
+func renumberDuplicateRevisions(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&BallotSet{}) || !db.Migrator().HasColumn(&BallotSet{}, "Revision") {
+		return nil
+	}
+	var duplicates []struct {
+		MatchID  uint
+		Revision int
+	}
+	if err := db.Model(&BallotSet{}).Select("match_id, revision").Where("revision > 0").
+		Group("match_id, revision").Having("COUNT(*) > 1").Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, dup := range duplicates {
+			var ids []uint
+			if err := tx.Unscoped().Model(&BallotSet{}).Where("match_id = ? AND revision = ?", dup.MatchID, dup.Revision).
+				Order("id asc").Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for _, id := range ids[1:] {
+				var max int
+				tx.Unscoped().Model(&BallotSet{}).Where("match_id = ?", dup.MatchID).Select("COALESCE(MAX(revision), 0)").Scan(&max)
+				if err := tx.Unscoped().Model(&BallotSet{}).Where("id = ?", id).Update("revision", max+1).Error; err != nil {
+					return err
+				}
+			}
+			var current int
+			tx.Model(&BallotSet{}).Where("match_id = ? AND status = ? AND is_trainee = ?", dup.MatchID, "confirmed", false).
+				Select("COALESCE(MAX(revision), 0)").Scan(&current)
+			if err := tx.Model(&Match{}).Where("id = ?", dup.MatchID).Update("current_ballot_revision", current).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// releaseLegacyDraws dijalankan sebelum AutoMigrate: saat kolom draw_status baru ditambahkan,
+// ronde lama yang sudah punya match atau sudah dipublikasikan ditandai released (bukan draft)
+func releaseLegacyDraws(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Round{}) || db.Migrator().HasColumn(&Round{}, "DrawStatus") {
+		return nil
+	}
+	if err := db.Migrator().AddColumn(&Round{}, "DrawStatus"); err != nil {
+		return err
+	}
+	if !db.Migrator().HasTable(&Match{}) {
+		return db.Model(&Round{}).Where("is_draw_published = ?", true).Update("draw_status", "released").Error
+	}
+	return db.Model(&Round{}).
+		Where("is_draw_published = ? OR id IN (?)", true, db.Model(&Match{}).Select("round_id")).
+		Update("draw_status", "released").Error
+}
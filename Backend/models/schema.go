@@ -92,14 +92,89 @@ type Tournament struct {
 	IsPublic    bool      `gorm:"default:true" json:"is_public"`
 }
 
+// TournamentSettings: Pengaturan tabulasi per turnamen
+type TournamentSettings struct {
+	gorm.Model
+	TournamentID uint   `gorm:"uniqueIndex" json:"tournament_id"`
+	ByeStrategy  string `gorm:"default:'bye_win'" json:"bye_strategy"` // "bye_win", "swing_team"
+
+	// Skor juri: skor akhir = (1 - w) * base score + w * rata-rata feedback (skala 0-10),
+	// dengan w naik bertahap sampai FeedbackWeight setelah FeedbackFullWeightAfter match dinilai
+	FeedbackWeight          float64 `gorm:"default:0.5" json:"feedback_weight"`
+	FeedbackFullWeightAfter int     `gorm:"default:3" json:"feedback_full_weight_after"`
+
+	// Jika aktif, hanya tim (semua speaker hadir), juri, dan ruangan yang sudah check-in
+	// dianggap tersedia untuk draw & alokasi
+	RequireCheckIn bool `gorm:"default:false" json:"require_check_in"`
+
+	// Ballot panel: "all" = match selesai setelah semua juri (chair + panellist) submit,
+	// "majority" = cukup mayoritas panel. Seri suara diputus "chair" atau "scores" (total skor rata-rata)
+	BallotsRequired string `gorm:"default:'all'" json:"ballots_required"`
+	PanelTieBreak   string `gorm:"default:'chair'" json:"panel_tie_break"`
+
+	// Jika aktif, ballot harus dikonfirmasi user lain sebelum dihitung (double entry)
+	RequireBallotConfirmation bool `gorm:"default:false" json:"require_ballot_confirmation"`
+
+	// Validasi skor ballot; nilai 0 = bawaan format turnamen. ScoreStep 1 = bilangan bulat, 0.5 = setengah poin
+	SpeakerScoreMin   float64 `gorm:"default:0" json:"speaker_score_min"`
+	SpeakerScoreMax   float64 `gorm:"default:0" json:"speaker_score_max"`
+	ReplyScoreMin     float64 `gorm:"default:0" json:"reply_score_min"`
+	ReplyScoreMax     float64 `gorm:"default:0" json:"reply_score_max"`
+	ScoreStep         float64 `gorm:"default:0" json:"score_step"`
+	SpeakersPerSide   int     `gorm:"default:0" json:"speakers_per_side"`
+	AllowLowPointWins bool    `gorm:"default:false" json:"allow_low_point_wins"`
+	AllowTiedScores   bool    `gorm:"default:false" json:"allow_tied_scores"`
+
+	// Skor tab speaker iron-person (satu speaker dua posisi): "first" = posisi pertama saja,
+	// "highest" = skor tertinggi, "both" = keduanya. Total tim selalu menghitung keduanya.
+	IronPersonTab string `gorm:"default:'first'" json:"iron_person_tab"`
+
+	// Hasil forfeit saat tim mundur: VP untuk lawan, dan speaker score lawan
+	// ("average" = rata-rata debat lain seperti bye, "none" = tidak dapat speaker score)
+	ForfeitWinPoints     int    `gorm:"default:1" json:"forfeit_win_points"`
+	ForfeitSpeakerScores string `gorm:"default:'average'" json:"forfeit_speaker_scores"`
+}
+
 // Adjudicator: Daftar Juri untuk Tournament
 type Adjudicator struct {
 	gorm.Model
-	TournamentID uint   `json:"tournament_id"`
-	Name         string `json:"name"`
-	Institution  string `json:"institution"`
-	Level        string `json:"level"` // "Chief", "Wing", "Panelist"
-	IsAvailable  bool   `gorm:"default:true" json:"is_available"`
+	TournamentID  uint     `json:"tournament_id"`
+	Name          string   `json:"name"`
+	Institution   string   `json:"institution"`
+	Level         string   `json:"level"` // "Chief", "Wing", "Panelist"
+	IsAvailable   bool     `gorm:"default:true" json:"is_available"`
+	NeedsAccess   bool     `gorm:"default:false" json:"needs_access"` // Butuh ruangan yang aksesibel
+	BaseScore     float64  `gorm:"default:0" json:"base_score"`       // Skor tes CA (0-10)
+	ScoreOverride *float64 `json:"score_override"`                    // Skor manual dari adjudicator core, menggantikan hasil hitung
+	Score         float64  `gorm:"default:0" json:"score"`            // Skor efektif di turnamen ini (dipakai alokasi otomatis)
+	PrivateKey    string   `gorm:"index" json:"-"`                    // Kunci rahasia untuk QR check-in
+}
+
+// AdjudicatorScoreHistory: Riwayat perubahan skor juri
+type AdjudicatorScoreHistory struct {
+	gorm.Model
+	AdjudicatorID uint     `gorm:"index" json:"adjudicator_id"`
+	TournamentID  uint     `json:"tournament_id"`
+	BaseScore     float64  `json:"base_score"`
+	ScoreOverride *float64 `json:"score_override"`
+	FeedbackAvg   float64  `json:"feedback_avg"` // Rata-rata feedback (skala 0-10) saat perubahan
+	FeedbackCount int      `json:"feedback_count"`
+	Score         float64  `json:"score"`
+	Source        string   `json:"source"` // "base", "override", "feedback", "recalculate"
+	Note          string   `json:"note"`
+}
+
+// AdjudicatorConflict: Juri yang tidak boleh menilai tim/institusi tertentu atau satu panel dengan juri lain.
+// Konflik institusi sendiri (Adjudicator.Institution) berlaku otomatis tanpa perlu dicatat.
+type AdjudicatorConflict struct {
+	gorm.Model
+	TournamentID       uint   `gorm:"index" json:"tournament_id"`
+	AdjudicatorID      uint   `gorm:"index" json:"adjudicator_id"`
+	ConflictType       string `json:"conflict_type"` // "team", "institution", "adjudicator"
+	TeamID             *uint  `json:"team_id"`
+	Institution        string `json:"institution"`
+	OtherAdjudicatorID *uint  `json:"other_adjudicator_id"`
+	Reason             string `json:"reason"` // "Mantan pelatih", "Pasangan speaker", dll
 }
 
 // Room: Daftar Ruangan untuk Tournament
@@ -110,6 +185,18 @@ type Room struct {
 	Location     string `json:"location"`
 	Capacity     int    `json:"capacity"`
 	IsAvailable  bool   `gorm:"default:true" json:"is_available"`
+	Priority     int    `gorm:"default:0" json:"priority"`          // Makin tinggi makin diutamakan (untuk bracket atas)
+	IsAccessible bool   `gorm:"default:false" json:"is_accessible"` // Bisa diakses kursi roda, dll
+}
+
+// RoomConstraint: Batasan ruangan untuk tim/juri tertentu, e.g. "UGM A harus di Gedung B"
+type RoomConstraint struct {
+	gorm.Model
+	TournamentID  uint   `json:"tournament_id"`
+	TeamID        *uint  `json:"team_id"`
+	AdjudicatorID *uint  `json:"adjudicator_id"`
+	Location      string `json:"location"` // Harus sama dengan Room.Location (gedung)
+	Reason        string `json:"reason"`
 }
 
 // Team: Peserta Turnamen
@@ -120,22 +207,51 @@ type Team struct {
 	Name         string     `json:"name"`        // "UGM A"
 	Institution  string     `json:"institution"` // "Universitas Gadjah Mada"
 	Speakers     []Speaker  `json:"speakers"`
+	IsSwing      bool       `gorm:"default:false" json:"is_swing"`     // Tim pengganti untuk jumlah tim ganjil
+	NeedsAccess  bool       `gorm:"default:false" json:"needs_access"` // Butuh ruangan yang aksesibel
+
+	// Pengunduran diri: tim tidak ikut draw mulai WithdrawnRoundID, match yang sudah dirilis jadi forfeit
+	Withdrawn        bool       `gorm:"default:false" json:"withdrawn"`
+	WithdrawnRoundID *uint      `json:"withdrawn_round_id"`
+	WithdrawnAt      *time.Time `json:"withdrawn_at"`
+	WithdrawalReason string     `json:"withdrawal_reason"`
 
 	// Statistik Tabulasi (Diupdate tiap ronde)
-	TotalVP      int `gorm:"default:0" json:"total_vp"`      // Victory Points
-	TotalSpeaker int `gorm:"default:0" json:"total_speaker"` // Total Speaker Score
-	Rank         int `gorm:"default:0" json:"rank"`
-	Wins         int `gorm:"default:0" json:"wins"`
-	Losses       int `gorm:"default:0" json:"losses"`
+	TotalVP      int     `gorm:"default:0" json:"total_vp"`      // Victory Points
+	TotalSpeaker float64 `gorm:"default:0" json:"total_speaker"` // Total Speaker Score (rata-rata panel, bisa pecahan)
+	Rank         int     `gorm:"default:0" json:"rank"`
+	Wins         int     `gorm:"default:0" json:"wins"`
+	Losses       int     `gorm:"default:0" json:"losses"`
 }
 
 type Speaker struct {
 	gorm.Model
-	TeamID      uint   `json:"team_id"`
-	Team        Team   `json:"team" gorm:"references:ID"`
-	Name        string `json:"name"`
-	TotalScore  int    `json:"total_score"`
-	SpeakerRank int    `json:"speaker_rank"`
+	TeamID       uint    `json:"team_id"`
+	Team         Team    `json:"team" gorm:"references:ID"`
+	Name         string  `json:"name"`
+	TotalScore   float64 `json:"total_score"` // Tanpa reply speech
+	SpeakerRank  int     `json:"speaker_rank"`
+	ReplyTotal   float64 `gorm:"default:0" json:"reply_total"` // Total skor reply speech (tab reply terpisah)
+	ReplyCount   int     `gorm:"default:0" json:"reply_count"`
+	IsSubstitute bool    `gorm:"default:false" json:"is_substitute"` // Cadangan terdaftar, tidak wajib check-in
+	PrivateKey   string  `gorm:"index" json:"-"`                     // Kunci rahasia untuk QR check-in
+
+	Categories []SpeakerCategory `json:"categories" gorm:"many2many:speaker_category_assignments"`
+}
+
+// SpeakerCategory: Kategori speaker per turnamen, e.g. "Novice", "ESL".
+// Tim eligible untuk sebuah kategori jika semua speaker-nya masuk kategori itu.
+type SpeakerCategory struct {
+	gorm.Model
+	TournamentID uint   `gorm:"index" json:"tournament_id"`
+	Name         string `json:"name"`
+	Priority     int    `gorm:"default:0" json:"priority"` // Urutan tampil tab kategori
+}
+
+// SpeakerCategoryAssignment: Speaker yang masuk sebuah kategori
+type SpeakerCategoryAssignment struct {
+	SpeakerID         uint `gorm:"primaryKey" json:"speaker_id"`
+	SpeakerCategoryID uint `gorm:"primaryKey" json:"speaker_category_id"`
 }
 
 type Round struct {
@@ -150,18 +266,57 @@ type Round struct {
 	MotionImage       string  `json:"motion_image"`        // Optional image for motion
 	Status            string  `json:"status"`              // "in_progress", "completed"
 	Matches           []Match `json:"matches"`
+
+	// Alur draw: draft -> confirmed -> released (terpisah dari IsDrawPublished)
+	DrawStatus     string     `gorm:"default:'draft'" json:"draw_status"`
+	DrawReleasedAt *time.Time `json:"draw_released_at"`
+
+	// Optimistic locking: naik setiap ronde diubah; klien mengirim versi yang diedit
+	Version int `gorm:"default:1" json:"version"`
+}
+
+// RoundAvailability: Ketersediaan tim/juri/ruangan per ronde.
+// Jika tidak ada record, dipakai flag global (Adjudicator.IsAvailable, Room.IsAvailable).
+type RoundAvailability struct {
+	gorm.Model
+	RoundID     uint   `gorm:"uniqueIndex:idx_round_availability" json:"round_id"`
+	EntityType  string `gorm:"uniqueIndex:idx_round_availability" json:"entity_type"` // "team", "adjudicator", "room"
+	EntityID    uint   `gorm:"uniqueIndex:idx_round_availability" json:"entity_id"`
+	IsAvailable bool   `json:"is_available"`
+}
+
+// CheckIn: Kehadiran speaker/juri/ruangan di sebuah ronde. Tidak ada record = belum check-in.
+type CheckIn struct {
+	gorm.Model
+	RoundID     uint      `gorm:"uniqueIndex:idx_check_in" json:"round_id"`
+	EntityType  string    `gorm:"uniqueIndex:idx_check_in" json:"entity_type"` // "speaker", "adjudicator", "room"
+	EntityID    uint      `gorm:"uniqueIndex:idx_check_in" json:"entity_id"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+	Method      string    `json:"method"` // "manual", "qr"
+}
+
+// DrawEdit: Riwayat edit manual draw (untuk undo)
+type DrawEdit struct {
+	gorm.Model
+	RoundID   uint   `json:"round_id"`
+	Operation string `json:"operation"`                 // "swap_teams", "flip_sides", "move_team"
+	Snapshot  string `gorm:"type:text" json:"snapshot"` // JSON posisi match sebelum edit
 }
 
 // Match: Struktur Hybrid (Bisa AP, Bisa BP)
 // Kita pakai teknik "Nullable Foreign Keys"
 type Match struct {
 	gorm.Model
-	RoundID       uint         `json:"round_id"`
-	RoomID        *uint        `json:"room_id"`
-	Room          *Room        `json:"room" gorm:"references:ID"`
-	AdjudicatorID *uint        `json:"adjudicator_id"`
-	Adjudicator   *Adjudicator `json:"adjudicator" gorm:"references:ID"`
-	PanelJudges   string       `json:"panel_judges"`
+	RoundID       uint               `json:"round_id"`
+	RoomID        *uint              `json:"room_id"`
+	Room          *Room              `json:"room" gorm:"references:ID"`
+	AdjudicatorID *uint              `json:"adjudicator_id"`
+	Adjudicator   *Adjudicator       `json:"adjudicator" gorm:"references:ID"` // Chair (sama dengan Panel role "chair")
+	Panel         []MatchAdjudicator `json:"panel"`
+	Importance    int                `gorm:"default:0" json:"importance"`     // Bobot manual untuk alokasi juri (makin tinggi makin penting)
+	IsForfeit     bool               `gorm:"default:false" json:"is_forfeit"` // Lawan mundur, WinnerID menang tanpa debat
+	// Versi ballot terbaru yang sedang berlaku (BallotSet.Revision), 0 jika belum ada
+	CurrentBallotRevision int `gorm:"default:0" json:"current_ballot_revision"`
 
 	// --- KOLOM ASIAN PARLIAMENTARY (2 Teams) ---
 	GovTeamID *uint  `json:"gov_team_id"`
@@ -188,23 +343,79 @@ type Match struct {
 	Rank4TeamID *uint `json:"rank4_team_id"`
 
 	IsCompleted bool `json:"is_completed"`
+	IsBye       bool `json:"is_bye"` // Hanya GovTeam yang terisi, otomatis menang
+
+	// Optimistic locking: naik setiap hasil match berubah (manual maupun dari ballot)
+	Version int `gorm:"default:1" json:"version"`
+}
+
+// MatchAdjudicator: Anggota panel juri sebuah match
+type MatchAdjudicator struct {
+	gorm.Model
+	MatchID       uint        `gorm:"index" json:"match_id"`
+	AdjudicatorID uint        `gorm:"index" json:"adjudicator_id"`
+	Adjudicator   Adjudicator `json:"adjudicator" gorm:"references:ID"`
+	Role          string      `json:"role"` // "chair", "panellist", "trainee"
+}
+
+// BallotSet: Ballot lengkap satu juri untuk satu match (skor semua speaker + keputusan).
+// Hasil match ditentukan mayoritas ballot set juri non-trainee di panel.
+type BallotSet struct {
+	gorm.Model
+	MatchID       uint        `gorm:"index;uniqueIndex:idx_ballot_sets_match_revision,where:revision > 0" json:"match_id"`
+	AdjudicatorID uint        `gorm:"index" json:"adjudicator_id"`
+	Adjudicator   Adjudicator `json:"adjudicator" gorm:"references:ID"`
+	Winner        string      `json:"winner"` // "gov" or "opp"
+	GovTotal      float64     `json:"gov_total"`
+	OppTotal      float64     `json:"opp_total"`
+	IsTrainee     bool        `gorm:"default:false" json:"is_trainee"`
+	Ballots       []Ballot    `json:"ballots"`
+
+	// Alur konfirmasi: draft -> submitted -> confirmed / discarded. Hanya "confirmed" yang dihitung.
+	Status        string     `gorm:"default:'confirmed';index" json:"status"`
+	SubmittedByID *uint      `json:"submitted_by_id"` // User tabulator yang menginput (kosong jika juri input sendiri)
+	ConfirmedByID *uint      `json:"confirmed_by_id"`
+	ConfirmedAt   *time.Time `json:"confirmed_at"`
+
+	// Riwayat: setiap pengajuan adalah versi baru (isi tidak pernah diubah), nomor urut per match.
+	// Draft belum punya nomor (0).
+	// Unik per match (kecuali draft), nomor dibagikan di bawah kunci baris match.
+	Revision int `gorm:"default:0;index;uniqueIndex:idx_ballot_sets_match_revision,where:revision > 0" json:"revision"`
+	// Optimistic locking: naik setiap status set berubah (confirm, discard, diganti versi lain)
+	Version int `gorm:"default:1" json:"version"`
+}
+
+// BallotSubmission: hasil submit ballot per submission_id dari klien, supaya pengiriman ulang
+// (koneksi putus, antrean offline) mengembalikan hasil yang sama tanpa membuat versi baru.
+type BallotSubmission struct {
+	gorm.Model
+	SubmissionID  string `gorm:"size:100;uniqueIndex" json:"submission_id"`
+	MatchID       uint   `gorm:"index" json:"match_id"`
+	AdjudicatorID uint   `json:"adjudicator_id"`
+	BallotSetID   uint   `json:"ballot_set_id"`
+	Response      string `gorm:"type:text" json:"-"` // Body respons submit pertama (JSON)
 }
 
 // Ballot: Lembar Skor Individu
 type Ballot struct {
 	gorm.Model
+	BallotSetID   uint        `gorm:"index" json:"ballot_set_id"`
 	MatchID       uint        `json:"match_id"`
 	AdjudicatorID uint        `json:"adjudicator_id"`
 	Adjudicator   Adjudicator `json:"adjudicator" gorm:"references:ID"`
 	SpeakerID     uint        `json:"speaker_id"`
 	Speaker       Speaker     `json:"speaker" gorm:"references:ID"`
-	Score         int         `json:"score"`  // AP (68-82), BP (60-80)
+	Score         float64     `json:"score"`  // AP (68-82), BP (60-80), boleh setengah poin sesuai settings
 	Winner        string      `json:"winner"` // "gov" or "opp"
 
 	// Identitas Peran (Penting buat BP)
 	Position string `json:"position"` // "PM", "LO", "Member", "Whip"
 	IsReply  bool   `json:"is_reply"`
 	TeamRole string `json:"team_role"` // "gov" or "opp"
+
+	IsTrainee bool `gorm:"default:false" json:"is_trainee"` // Ballot juri trainee: disimpan untuk latihan, tidak dihitung
+
+	IsIronPerson bool `gorm:"default:false" json:"is_iron_person"` // Speaker mengisi dua posisi di ballot ini
 }
 
 // AdjudicatorFeedback: Feedback dan Rating dari User untuk Juri
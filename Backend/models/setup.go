@@ -29,12 +29,39 @@ func ConnectDatabase() {
 		log.Fatal("❌ Gagal konek ke database!", err)
 	}
 
+	// Nomor versi ballot ganda dirapikan dulu sebelum unique index (match_id, revision) dibuat
+	if err := renumberDuplicateRevisions(database); err != nil {
+		log.Fatal("❌ Gagal migrasi tabel:", err)
+	}
+	// Ronde lama (sebelum ada alur draw) yang sudah punya draw jangan ikut menjadi draft
+	if err := releaseLegacyDraws(database); err != nil {
+		log.Fatal("❌ Gagal migrasi tabel:", err)
+	}
+
 	// Auto Migrate (Biar tabel otomatis dibuat di Supabase)
 	err = database.AutoMigrate(
 		&User{}, &Member{}, &Article{}, &CompetitionHistory{}, &Achievement{},
 		&Tournament{}, &Team{}, &Speaker{}, &Round{}, &Match{}, &Ballot{},
 		&Adjudicator{}, &Room{}, &AdjudicatorFeedback{},
+		&TournamentSettings{}, &DrawEdit{}, &RoomConstraint{}, &RoundAvailability{},
+		&MatchAdjudicator{}, &AdjudicatorConflict{}, &AdjudicatorScoreHistory{},
+		&CheckIn{}, &SpeakerCategory{}, &SpeakerCategoryAssignment{},
+		&BallotSet{},
+		&BallotSubmission{},
 	)
+	if err == nil {
+		err = migrateLegacyPanels(database)
+	}
+	if err == nil {
+		err = migrateLegacyBallots(database)
+	}
+	if err == nil {
+		err = numberBallotRevisions(database)
+	}
+
+	if err != nil {
+		log.Fatal("❌ Gagal migrasi tabel:", err)
+	}
 
 	DB = database
 	fmt.Println("✅ SUKSES: Database Terhubung!")
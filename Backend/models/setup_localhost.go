@@ -24,6 +24,15 @@ func ConnectDatabaseLocalhost() {
 		log.Fatal("❌ Gagal konek ke database!", err)
 	}
 
+	// Nomor versi ballot ganda dirapikan dulu sebelum unique index (match_id, revision) dibuat
+	if err := renumberDuplicateRevisions(database); err != nil {
+		log.Fatal("❌ Gagal migrasi tabel:", err)
+	}
+	// Ronde lama (sebelum ada alur draw) yang sudah punya draw jangan ikut menjadi draft
+	if err := releaseLegacyDraws(database); err != nil {
+		log.Fatal("❌ Gagal migrasi tabel:", err)
+	}
+
 	// AUTO MIGRATE: Daftarkan SEMUA Struct baru di sini
 	err = database.AutoMigrate(
 		&User{},
@@ -33,17 +42,38 @@ func ConnectDatabaseLocalhost() {
 		&CompetitionHistory{}, // <-- Baru
 		&Achievement{},
 		// Tabulation System
-		&Tournament{},  // <-- Baru
-		&Adjudicator{}, // <-- Juri
-		&Room{},        // <-- Ruangan
+		&Tournament{},              // <-- Baru
+		&TournamentSettings{},      // <-- Pengaturan tabulasi
+		&Adjudicator{},             // <-- Juri
+		&AdjudicatorScoreHistory{}, // <-- Riwayat skor juri
+		&Room{},                    // <-- Ruangan
+		&RoomConstraint{},          // <-- Batasan ruangan
 		&Team{},
 		&Speaker{},
+		&SpeakerCategory{},           // <-- Kategori speaker (Novice, ESL, ...)
+		&SpeakerCategoryAssignment{}, // <-- Speaker per kategori
 		&Round{},
+		&RoundAvailability{},
+		&CheckIn{}, // <-- Check-in peserta per ronde
 		&Match{},
+		&MatchAdjudicator{},    // <-- Panel juri per match
+		&AdjudicatorConflict{}, // <-- Konflik juri
+		&DrawEdit{},
+		&BallotSet{},        // <-- Ballot per juri per match
+		&BallotSubmission{}, // <-- Replay submit ballot (submission_id)
 		&Ballot{},
 		&AdjudicatorFeedback{}, // <-- Feedback Juri
 		// Motion (opsional jika dipisah)
 	)
+	if err == nil {
+		err = migrateLegacyPanels(database)
+	}
+	if err == nil {
+		err = migrateLegacyBallots(database)
+	}
+	if err == nil {
+		err = numberBallotRevisions(database)
+	}
 
 	if err != nil {
 		log.Fatal("❌ Gagal migrasi tabel:", err)
@@ -444,7 +444,7 @@ func main() {
 				ballot := models.Ballot{
 					MatchID:   match.ID,
 					SpeakerID: speakers[ballotData.SpeakerIdx].ID,
-					Score:     ballotData.Score,
+					Score:     float64(ballotData.Score),
 					Position:  ballotData.Position,
 					TeamRole:  ballotData.TeamRole,
 					IsReply:   false,